@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/hex"
 	"fmt"
 	"runtime"
 
@@ -17,12 +18,24 @@ type CreateAccountPage struct {
 	pass1LineEdit *walk.LineEdit
 	pass2LineEdit *walk.LineEdit
 	memoLineEdit *walk.LineEdit
+	hdPathLineEdit *walk.LineEdit
+	bip39PassLineEdit *walk.LineEdit
+	wordsComboBox *walk.ComboBox
+	languageComboBox *walk.ComboBox
+	languages []string
+	entropyComboBox *walk.ComboBox
+	entropyLineEdit *walk.LineEdit
 	progressTextEdit *walk.TextEdit
 	caButton *walk.PushButton
 }
 
+// userEntropyKinds lists the choices of entropyComboBox, where the empty kind means no user entropy
+var userEntropyKinds = []string{"", keykeeper.EntropyDice, keykeeper.EntropyCoins, keykeeper.EntropyHex}
+
 func newCreateAccountPage(parent walk.Container, _ interface{}) (Page, error) {
 	p := new(CreateAccountPage)
+	entropyNames := []string{T("entropyNone"), T("entropyDice"), T("entropyCoins"), T("entropyHex")}
+	p.languages = keykeeper.AvailableLanguages()
 
 	if err := (Composite{
 		AssignTo: &p.Composite,
@@ -36,6 +49,10 @@ func newCreateAccountPage(parent walk.Container, _ interface{}) (Page, error) {
 			Label{Text: T("caline5")},
 			Label{Text: T("caline6")},
 			Label{Text: T("caline7")},
+			Label{Text: T("caline8")},
+			Label{Text: T("caline9")},
+			Label{Text: T("caline10")},
+			Label{Text: T("caline11")},
 			Composite{
 				Layout:        Grid{Columns: 2},
 				StretchFactor: 4,
@@ -56,6 +73,34 @@ func newCreateAccountPage(parent walk.Container, _ interface{}) (Page, error) {
 					},
 					Label{Text: T("memo")},
 					LineEdit{AssignTo: &p.memoLineEdit},
+					Label{Text: T("hdPath")},
+					LineEdit{
+						AssignTo: &p.hdPathLineEdit,
+						Text:     keykeeper.DefaultHDPath.String(),
+					},
+					Label{Text: T("bip39Passphrase")},
+					LineEdit{AssignTo: &p.bip39PassLineEdit},
+					Label{Text: T("mnemonicWords")},
+					ComboBox{
+						AssignTo:     &p.wordsComboBox,
+						Model:        keykeeper.MnemonicWordCounts,
+						Format:       "%d",
+						CurrentIndex: len(keykeeper.MnemonicWordCounts) - 1,
+					},
+					Label{Text: T("mnemonicLanguage")},
+					ComboBox{
+						AssignTo:     &p.languageComboBox,
+						Model:        languageNames(p.languages),
+						CurrentIndex: 0,
+					},
+					Label{Text: T("userEntropy")},
+					ComboBox{
+						AssignTo:     &p.entropyComboBox,
+						Model:        entropyNames,
+						CurrentIndex: 0,
+					},
+					Label{Text: T("entropyInput")},
+					LineEdit{AssignTo: &p.entropyLineEdit},
 					Label{Text: T("progress")},
 					TextEdit{
 						AssignTo: &p.progressTextEdit,
@@ -99,6 +144,36 @@ func runCreateAccount(p *CreateAccountPage) {
 		return
 	}
 
+	hdPath, err := keykeeper.ParseHDPath(p.hdPathLineEdit.Text())
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	bip39Pass := p.bip39PassLineEdit.Text()
+	words := keykeeper.DefaultMnemonicWords
+	if i := p.wordsComboBox.CurrentIndex(); i >= 0 {
+		words = keykeeper.MnemonicWordCounts[i]
+	}
+	tmpl := keykeeper.MnemonicKey{Path: hdPath, BIP39Passphrase: bip39Pass}
+	if i := p.languageComboBox.CurrentIndex(); i >= 0 {
+		tmpl.Language = p.languages[i]
+	}
+	var mix keykeeper.EntropyMix
+	if i := p.entropyComboBox.CurrentIndex(); i > 0 {
+		user, err := keykeeper.ParseUserEntropy(userEntropyKinds[i], p.entropyLineEdit.Text())
+		if err == nil {
+			mix, err = keykeeper.NewEntropyMix(user, words)
+		}
+		if err != nil {
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
+		}
+		if bits := len(mix.Entropy) * 8; user.Bits() < float64(bits) {
+			walk.MsgBox(MainWin, T("warn"), fmt.Sprintf(T("weakUserEntropy"), user.Bits(), bits),
+				walk.MsgBoxIconWarning|walk.MsgBoxApplModal)
+		}
+	}
+
 	prefix := p.prefixLineEdit.Text()
 	s, ok := keykeeper.CheckValid(prefix)
 	if !ok {
@@ -124,7 +199,7 @@ func runCreateAccount(p *CreateAccountPage) {
 	coreCount := runtime.NumCPU()
 	p.caButton.SetEnabled(false)
 	go func() {
-		addr, mnemonic := keykeeper.GenerateMnemonic(prefix, suffix, func(count uint64, percent float64) {
+		addr, mnemonic, index, err := keykeeper.GenerateMnemonic(prefix, suffix, tmpl, words, mix.Entropy, func(count uint64, percent float64) {
 			MainWin.Synchronize(func() {
 				s := fmt.Sprintf(T("estimate_progress"), count, percent)
 				p.progressTextEdit.SetText(s)
@@ -132,10 +207,25 @@ func runCreateAccount(p *CreateAccountPage) {
 			})
 		}, coreCount)
 		MainWin.Synchronize(func() {
+			if err != nil {
+				walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+				p.caButton.SetEnabled(true)
+				return
+			}
 			p.progressTextEdit.AppendText(fmt.Sprintf("===== %s ======\r\n", T("mnemonic")))
 			p.progressTextEdit.AppendText(fmt.Sprintf("%s\r\n", mnemonic))
+			if bip39Pass != "" {
+				p.progressTextEdit.AppendText(fmt.Sprintf(T("bip39PassphraseIs")+"\r\n", bip39Pass))
+			}
 			p.progressTextEdit.AppendText(fmt.Sprintf("===== %s ======\r\n", T("address")))
 			p.progressTextEdit.AppendText(fmt.Sprintf("%s\r\n", addr))
+			if mix.System != nil {
+				// publishing these along with the user entropy allows anyone to reproduce the mnemonic
+				p.progressTextEdit.AppendText(fmt.Sprintf("===== %s ======\r\n", T("systemEntropy")))
+				p.progressTextEdit.AppendText(fmt.Sprintf("%s\r\n", hex.EncodeToString(mix.System)))
+				p.progressTextEdit.AppendText(fmt.Sprintf("===== %s ======\r\n", T("candidateIndex")))
+				p.progressTextEdit.AppendText(fmt.Sprintf("%d\r\n", index))
+			}
 			p.progressTextEdit.SetFocus()
 
 			if !keykeeper.KB.IsOpen() {
@@ -143,9 +233,10 @@ func runCreateAccount(p *CreateAccountPage) {
 				return
 			}
 
-			_, err := keykeeper.CreateAccount(memo, mnemonic, pass1)
+			tmpl.Mnemonic = mnemonic
+			_, err = keykeeper.CreateAccountWithKey(memo, tmpl, pass1)
 			if err != nil {
-				walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+				walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 			} else {
 				walk.MsgBox(MainWin, T("success"), T("successCA")+addr, walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
 			}
@@ -17,13 +17,18 @@ import (
 
 // prompt user to enter a passphrase
 func ShowPassphraseDialog(owner walk.Form, okCallback func(pass string)) {
+	ShowPassphraseDialogWithTitle(owner, T("enterEncryptPassphrase"), okCallback)
+}
+
+// prompt user to enter a passphrase, with a customized title
+func ShowPassphraseDialogWithTitle(owner walk.Form, title string, okCallback func(pass string)) {
 	var dlg *walk.Dialog
 	var okPB, cancelPB *walk.PushButton
 	var passLineEdit *walk.LineEdit
 
 	var dialog = Dialog{}
 	dialog.AssignTo = &dlg
-	dialog.Title = T("enterEncryptPassphrase")
+	dialog.Title = title
 	dialog.MinSize = Size{300, 200}
 	dialog.Layout = VBox{}
 	dialog.DefaultButton = &okPB
@@ -33,7 +38,7 @@ func ShowPassphraseDialog(owner walk.Form, okCallback func(pass string)) {
 		Composite{
 			Layout: Grid{Columns: 2},
 			Children: []Widget{
-				Label{Text: T("enterEncryptPassphrase")},
+				Label{Text: title},
 				LineEdit{
 					AssignTo: &passLineEdit,
 					PasswordMode: true,
@@ -132,6 +137,84 @@ func ShowChangePassphraseDialog(owner walk.Form, okCallback func(oldPass, newPas
 	dialog.Run(owner)
 }
 
+// prompt user to type a new passphrase twice
+func ShowNewPassphraseDialog(owner walk.Form, title string, okCallback func(newPass string)) {
+	var dlg *walk.Dialog
+	var okPB, cancelPB *walk.PushButton
+	var passNew1LineEdit, passNew2LineEdit *walk.LineEdit
+
+	var dialog = Dialog{}
+	dialog.AssignTo = &dlg
+	dialog.Title = title
+	dialog.MinSize = Size{300, 200}
+	dialog.Layout = VBox{}
+	dialog.DefaultButton = &okPB
+	dialog.CancelButton = &cancelPB
+
+	childrens := []Widget{
+		Composite{
+			Layout: Grid{Columns: 2},
+			Children: []Widget{
+				Label{Text: T("enterEncryptPassphrase")},
+				LineEdit{
+					AssignTo: &passNew1LineEdit,
+					PasswordMode: true,
+				},
+				Label{Text: T("retypeEncryptPassphrase")},
+				LineEdit{
+					AssignTo: &passNew2LineEdit,
+					PasswordMode: true,
+				},
+			},
+		},
+		Composite{
+			Layout: HBox{},
+			Children: []Widget{
+				HSpacer{},
+				PushButton{
+					AssignTo: &okPB,
+					Text:     T("ok"),
+					OnClicked: func() {
+						pass1 := passNew1LineEdit.Text()
+						pass2 := passNew2LineEdit.Text()
+						if pass1 != pass2 {
+							walk.MsgBox(MainWin, T("error!"), T("mismatchPassphrase"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						if len(pass1) == 0 {
+							walk.MsgBox(MainWin, T("error!"), T("emptyPassphrase"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						okCallback(pass1)
+						dlg.Accept()
+					},
+				},
+				PushButton{
+					AssignTo:  &cancelPB,
+					Text:      T("cancel"),
+					OnClicked: func() { dlg.Cancel() },
+				},
+			},
+		},
+	}
+	dialog.Children = childrens
+	dialog.Run(owner)
+}
+
+// prompt user to enter the passphrase of a sealed keybase, it is used as keykeeper.PassphrasePrompt
+func promptKeybasePassphrase() (string, error) {
+	var passphrase string
+	entered := false
+	ShowPassphraseDialogWithTitle(MainWin, T("enterKeybasePassphrase"), func(pass string) {
+		passphrase = pass
+		entered = true
+	})
+	if !entered {
+		return "", errors.New(T("canceled"))
+	}
+	return passphrase, nil
+}
+
 // continuously read images from webcam and scan QRCode in the images
 // When a valid QRCode is obtained, okCallback will be invoked with it and dlg will be closed
 func runJob(webcam *gocv.VideoCapture, imageView *walk.ImageView, mat *gocv.Mat,
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/cloudfoundry/jibber_jabber"
 	"github.com/qor/i18n"
+
+	"github.com/coinexchain/ColdWallet.win/keykeeper"
+	"github.com/coinexchain/ColdWallet.win/msg"
 )
 
 var LC string
@@ -13,6 +21,167 @@ func T(s string) string {
 	return string(I18n.T(LC, s))
 }
 
+// errKeys maps the errors from keykeeper and msg to the keys of their translations
+var errKeys = []struct {
+	err error
+	key string
+}{
+	{keykeeper.ErrNoSuchAccount, "errNoSuchAccount"},
+	{keykeeper.ErrBadPassphrase, "errBadPassphrase"},
+	{keykeeper.ErrDecryptFailed, "errDecryptFailed"},
+	{keykeeper.ErrInvalidMnemonic, "errInvalidMnemonic"},
+	{keykeeper.ErrCorruptedAccount, "errCorruptedAccount"},
+	{keykeeper.ErrUnsupportedKdf, "errUnsupportedKdf"},
+	{keykeeper.ErrInvalidKeybase, "errInvalidKeybase"},
+	{keykeeper.ErrNotPlainFile, "errNotPlainFile"},
+	{keykeeper.ErrUnsupportedScheme, "errUnsupportedScheme"},
+	{keykeeper.ErrBadKeybasePassphrase, "errBadKeybasePassphrase"},
+	{keykeeper.ErrKeybasePassphraseNeeded, "errKeybasePassphraseNeeded"},
+	{keykeeper.ErrAlreadySealed, "alreadySealed"},
+	{keykeeper.ErrNotSealed, "notSealed"},
+	{keykeeper.ErrUnsealFirst, "errUnsealFirst"},
+	{keykeeper.ErrEmptyPassphrase, "errEmptyPassphrase"},
+	{keykeeper.ErrKeybaseLocked, "errKeybaseLocked"},
+	{keykeeper.ErrReadOnly, "errReadOnly"},
+	{keykeeper.ErrInvalidHDPath, "errInvalidHDPath"},
+	{keykeeper.ErrNotOpen, "errNotOpen"},
+	{keykeeper.ErrAccountExists, "errAccountExists"},
+	{keykeeper.ErrInvalidPrivKey, "errInvalidPrivKey"},
+	{keykeeper.ErrUnsupportedKeyType, "errUnsupportedKeyType"},
+	{keykeeper.ErrNoMnemonic, "errNoMnemonic"},
+	{keykeeper.ErrInvalidKeystore, "errInvalidKeystore"},
+	{keykeeper.ErrNotKeyring, "errNotKeyring"},
+	{keykeeper.ErrNotLocalKey, "errNotLocalKey"},
+	{keykeeper.ErrNoSuchWallet, "errNoSuchWallet"},
+	{keykeeper.ErrWalletExists, "errWalletExists"},
+	{keykeeper.ErrInvalidWordCount, "errInvalidWordCount"},
+	{keykeeper.ErrInvalidEntropy, "errInvalidEntropy"},
+	{keykeeper.ErrEntropyHealth, "errEntropyHealth"},
+	{keykeeper.ErrUnsupportedLanguage, "errUnsupportedLanguage"},
+	{keykeeper.ErrInvalidWordlist, "errInvalidWordlist"},
+	{msg.ErrUnknownTxType, "errUnknownTxType"},
+	{msg.ErrInvalidVoteOption, "errInvalidVoteOption"},
+	{msg.ErrInvalidRawTx, "errInvalidRawTx"},
+}
+
+// TErr translates an error before it is shown to the user.
+// The structured fields carried by the error are appended to the translated text.
+func TErr(err error) string {
+	var verErr *keykeeper.FormatVersionError
+	if errors.As(err, &verErr) {
+		return fmt.Sprintf(T("errFormatVersion"), verErr.Version, verErr.Supported)
+	}
+	var tamperErr *keykeeper.TamperError
+	if errors.As(err, &tamperErr) {
+		return tamperText(tamperErr)
+	}
+	var mnemonicErr *keykeeper.MnemonicError
+	if errors.As(err, &mnemonicErr) {
+		return mnemonicText(mnemonicErr)
+	}
+	text := err.Error()
+	for _, ek := range errKeys {
+		if errors.Is(err, ek.err) {
+			text = T(ek.key)
+			break
+		}
+	}
+	var accErr *keykeeper.AccountError
+	var fieldErr *msg.FieldError
+	var pathErr *os.PathError
+	switch {
+	case errors.As(err, &accErr):
+		return text + ": " + accErr.Address
+	case errors.As(err, &fieldErr):
+		return text + ": " + fieldErr.Value
+	case errors.As(err, &pathErr):
+		return text + ": " + pathErr.Path
+	}
+	return text
+}
+
+func tamperText(e *keykeeper.TamperError) string {
+	if e.ManifestInvalid {
+		return T("errTampered") + "\r\n" + T("manifestInvalid")
+	}
+	if e.ProtectionRemoved {
+		return T("errTampered") + "\r\n" + T("protectionRemoved")
+	}
+	lines := []string{T("errTampered")}
+	for _, l := range []struct {
+		key   string
+		addrs []string
+	}{{"modifiedAccs", e.Modified}, {"addedAccs", e.Added}, {"removedAccs", e.Removed}} {
+		if len(l.addrs) != 0 {
+			lines = append(lines, T(l.key)+"\r\n    "+strings.Join(l.addrs, "\r\n    "))
+		}
+	}
+	if e.Reordered {
+		lines = append(lines, T("reorderedAccs"))
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+func mnemonicText(e *keykeeper.MnemonicError) string {
+	switch {
+	case e.Position != 0:
+		return T("errInvalidMnemonic") + ": " + fmt.Sprintf(T("unknownWord"), e.Position, e.Word)
+	case e.BadChecksum:
+		return T("errInvalidMnemonic") + ": " + T("badChecksum")
+	}
+	return T("errInvalidMnemonic") + ": " + fmt.Sprintf(T("badWordCount"), e.WordCount)
+}
+
+// maxChecksumFixes limits the checksum fixes shown by diagnosisText
+const maxChecksumFixes = 10
+
+// diagnosisText explains the diagnosis of the mnemonic entry assistant, one finding per line
+func diagnosisText(d keykeeper.MnemonicDiagnosis) string {
+	if len(d.Words) == 0 {
+		return ""
+	}
+	lines := []string{fmt.Sprintf(T("wordsEntered"), len(d.Words))}
+	if d.Valid {
+		lines = append(lines, T("mnemonicOK"))
+	}
+	if len(d.Completions) != 0 {
+		lines = append(lines, T("completions")+strings.Join(d.Completions, ", "))
+	}
+	for _, w := range d.Words {
+		if w.Known || w.Partial {
+			continue
+		}
+		line := fmt.Sprintf(T("unknownWord"), w.Position, w.Word)
+		if len(w.Suggestions) != 0 {
+			line += "; " + T("didYouMean") + strings.Join(w.Suggestions, ", ")
+		}
+		lines = append(lines, line)
+	}
+	if d.BadChecksum {
+		lines = append(lines, T("badChecksum"))
+	}
+	if len(d.ChecksumFixes) != 0 {
+		lines = append(lines, T("checksumFixes"))
+		for i, f := range d.ChecksumFixes {
+			if i == maxChecksumFixes {
+				lines = append(lines, fmt.Sprintf(T("moreChecksumFixes"), len(d.ChecksumFixes)-i))
+				break
+			}
+			lines = append(lines, fmt.Sprintf(T("checksumFix"), f.Position, f.Word))
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// languageNames translates the languages of BIP39 wordlists for a ComboBox
+func languageNames(languages []string) []string {
+	names := make([]string, len(languages))
+	for i, lang := range languages {
+		names[i] = T("lang_" + lang)
+	}
+	return names
+}
+
 func add(key, en, cn string) {
 	I18n.AddTranslation(&i18n.Translation{
 		Key:    key,
@@ -35,7 +204,14 @@ func init() {
 	add("delete", "Delete", "删除")
 	add("exit", "Exit", "退出程序")
 	add("open", "Open Keybase", "打开私钥数据库")
+	add("openReadOnly", "Open Keybase Read-Only", "以只读方式打开私钥数据库")
+	add("readOnly", "Read-Only", "只读")
+	add("openReadOnly?", "Do you want to open it read-only?", "您是否要以只读方式打开它？")
 	add("create&open", "Create and Open a Keybase", "创建并打开私钥数据库")
+	add("restoreBackup", "Restore a Backup of the Keybase", "从备份中恢复私钥数据库")
+	add("keybasePassphrase", "Set the Passphrase of the Keybase", "设置私钥数据库的口令")
+	add("sealKeybase", "Encrypt the Whole Keybase", "加密整个私钥数据库")
+	add("unsealKeybase", "Stop Encrypting the Whole Keybase", "取消对整个私钥数据库的加密")
 	add("file", "File", "文件")
 	add("help", "Help", "帮助")
 	add("scanQRCode", "Scan QRCode", "扫描二维码")
@@ -50,6 +226,7 @@ func init() {
 	add("aboutContent", "Create, manage accounts and sign transactions with accounts' private keys",
 		"创建、管理账户，以及使用账户的私钥来签署交易")
 	add("selectOpen", "Please Select a File containing the Keybase", "请选择一个包含私钥数据库的文件")
+	add("selectBackup", "Please Select a Backup of the Keybase", "请选择私钥数据库的一个备份")
 	add("selectSave", "Please Select a File to contain the Keybase", "请选择一个文件用于保存私钥数据库")
 	add("ca", "Create Account", "创建账户")
 	add("la", "List Account", "账户列表")
@@ -71,10 +248,122 @@ func init() {
 	add("failOpenWebcam", "Failed to open the Webcam", "摄像头打开失败")
 	add("retypeEncryptPassphrase", "Retype Passphrase for Encryption", "再次确认加密口令")
 	add("enterEncryptPassphrase", "Enter the Passphrase for Encryption", "请输入加密口令")
+	add("enterKeybasePassphrase", "Enter the Passphrase of the Keybase", "请输入私钥数据库的口令")
+	add("canceled", "Canceled by the user", "用户已取消")
+	add("alreadySealed", "The whole keybase is already encrypted", "整个私钥数据库已经是加密的")
+	add("notSealed", "The whole keybase is not encrypted", "整个私钥数据库并未加密")
+	add("successSeal", "Success in encrypting the whole keybase. Its old backups have been removed.",
+		"已成功加密整个私钥数据库，它的旧备份已被删除。")
+	add("successUnseal", "The whole keybase is not encrypted any more. Its passphrase is still used to detect tampering.",
+		"整个私钥数据库已不再加密。它的口令仍被用于检测篡改。")
+	add("successKeybasePassphrase", "Success in setting the passphrase of the keybase", "私钥数据库的口令已设置成功")
+	add("errUnsealFirst", "The whole keybase is encrypted, please stop encrypting it before removing its passphrase",
+		"整个私钥数据库是加密的，请先取消加密，再移除它的口令")
+	add("errEmptyPassphrase", "The whole keybase can not be encrypted with an empty passphrase", "不可以用空口令加密整个私钥数据库")
+	add("errKeybaseLocked", "The keybase is being used by another program, please close it there first",
+		"私钥数据库正在被另一个程序使用，请先在那里关闭它")
+	add("errReadOnly", "The keybase is opened read-only, it can not be changed", "私钥数据库是以只读方式打开的，不能被修改")
+	add("errInvalidHDPath", "Invalid HD path, it should be like m/44'/688'/0'/0/0", "无效的HD路径，它应该形如m/44'/688'/0'/0/0")
+	add("errNotOpen", "The keybase is not opened", "私钥数据库没有被打开")
+	add("errNoSuchWallet", "No such wallet", "没有这个钱包")
+	add("errWalletExists", "The wallet already exists", "这个钱包已经存在")
+	add("errInvalidWordCount", "The number of mnemonic words must be 12, 15, 18, 21 or 24", "助记词的单词数必须是12、15、18、21或24")
+	add("errInvalidEntropy", "Invalid user entropy", "无效的用户熵")
+	add("errUnsupportedLanguage", "The wordlist of the language is not available", "该语言的单词表不可用")
+	add("errInvalidWordlist", "Invalid wordlist file, it must be the official file of BIP39", "无效的单词表文件，它必须是BIP39的官方文件")
+	add("errEntropyHealth", "The system random number generator looks broken, no keys can be generated on this computer",
+		"系统随机数生成器似乎已损坏，不能在这台计算机上生成密钥")
+	add("errAccountExists", "The account already exists", "这个账户已经存在")
+	add("unknownWord", "word %d \"%s\" is not in the word list", "第%d个单词\"%s\"不在词表中")
+	add("badChecksum", "the checksum does not match, please check the words", "校验和不匹配，请检查各个单词")
+	add("badWordCount", "it has %d words, but must have 12, 15, 18, 21 or 24", "它有%d个单词，但必须是12、15、18、21或24个")
+	add("account", "Account", "账户")
+	add("importMnemonic", "Import Mnemonic", "导入助记词")
+	add("imline1", "Enter the mnemonic you hold, its words can be separated by spaces or new lines.",
+		"请输入您持有的助记词，单词之间可以用空格或换行分隔。")
+	add("successImport", "Success in importing an account: ", "账户导入成功：")
+	add("importPrivKey", "Import Private Key", "导入私钥")
+	add("ipkline1", "Enter a private key in hex, or paste the armored key from `cetcli keys export` or a Web3 keystore JSON along with its passphrase.",
+		"请输入十六进制的私钥，或者粘贴`cetcli keys export`导出的私钥或Web3 keystore JSON，并输入其口令。")
+	add("privKey", "Private Key", "私钥")
+	add("armorPassphrase", "Passphrase of Exported Key", "导出私钥的口令")
+	add("showPrivKey", "Show Private Key", "显示私钥")
+	add("privKeyOf", "The private key of %s", "%s的私钥")
+	add("importKeyring", "Import from cetcli Keyring", "从cetcli密钥库导入")
+	add("selectKeyringHome", "Please Select the Home Folder of cetcli, such as .cetcli", "请选择cetcli的主目录，例如.cetcli")
+	add("ikline1", "Select the keys to import, each of them will be decrypted with its keyring passphrase.",
+		"请选择要导入的密钥，每个密钥将用它在密钥库中的口令解密。")
+	add("enterKeyringPassphrase", "Enter the Keyring Passphrase of %s", "请输入%s在密钥库中的口令")
+	add("noKeyringKeys", "There are no private keys in the keyring", "密钥库中没有私钥")
+	add("successImportKeyring", "Success in importing these accounts:", "以下账户导入成功：")
+	add("errNotKeyring", "No keyring of cetcli is found in the folder", "文件夹中没有cetcli的密钥库")
+	add("errNotLocalKey", "The private key is not held in the keyring", "密钥库中没有这个私钥")
+	add("exportKeys", "Export Private Keys", "导出私钥")
+	add("exportKeystore", "Export Keystore", "导出Keystore")
+	add("keystorePassphrase", "Passphrase for the Keystore", "Keystore的口令")
+	add("selectKeystoreFile", "Please Select a File to contain the Keystore", "请选择一个文件用于保存Keystore")
+	add("successExportKeystore", "The keystore is exported into ", "Keystore已导出到")
+	add("errInvalidKeystore", "Invalid keystore", "无效的Keystore")
+	add("exportPassphrase", "Passphrase for the Exported Keys", "导出私钥的口令")
+	add("selectExportDir", "Please Select a Folder for the Exported Keys", "请选择一个文件夹用于保存导出的私钥")
+	add("enterPassphraseOf", "Enter the Passphrase of %s", "请输入%s的口令")
+	add("successExport", "The keys are exported into these files, which can be imported by `cetcli keys import`:",
+		"私钥已导出到以下文件，它们可以用`cetcli keys import`导入：")
+	add("errInvalidPrivKey", "Invalid private key", "无效的私钥")
+	add("errUnsupportedKeyType", "Unsupported key type", "不支持的密钥类型")
+	add("errNoMnemonic", "The account has no mnemonic, it holds a raw private key", "这个账户没有助记词，它保存的是私钥")
+	add("errTampered", "The keybase has been modified since it was last saved by this program!", "私钥数据库在本程序上次保存之后被修改过！")
+	add("manifestInvalid", "Its integrity record is missing or modified.", "它的完整性记录丢失或被修改。")
+	add("protectionRemoved", "Its keybase passphrase and integrity record have been removed, please check every address and memo.",
+		"它的口令和完整性记录已被删除，请检查每个地址和备忘。")
+	add("modifiedAccs", "Modified accounts:", "被修改的账户：")
+	add("addedAccs", "Added accounts:", "被添加的账户：")
+	add("removedAccs", "Removed accounts:", "被删除的账户：")
+	add("reorderedAccs", "The order of the accounts has been changed.", "账户的顺序被改变了。")
+	add("unprotectedKeybase", "This keybase has no keybase passphrase, so its accounts are not protected against tampering. "+
+		"If you have set a keybase passphrase for it, it has been removed by someone else, and you should check every address and memo. "+
+		"Otherwise, set one with \"Set the Passphrase of the Keybase\".",
+		"这个私钥数据库没有口令，所以它的账户没有防篡改保护。如果您曾为它设置过口令，那么口令已被他人删除，您应检查每个地址和备忘。"+
+			"否则，请用“设置私钥数据库的口令”设置一个口令。")
+	add("acceptTampered?", "Do you want to accept the current content as legitimate and open it?",
+		"您是否要将当前内容作为合法内容接受并打开它？")
 	add("changeEncryptPassphrase", "Change the Passphrase for Encryption", "更新加密口令")
 	add("enterOldEncryptPassphrase", "Enter the Old Passphrase for Encryption", "请输入旧的加密口令")
 	add("belowNewEncryptPassphrase", "Enter the New Passphrase Below", "在下方输入新的口令")
 	add("memo", "Memo", "备忘")
+	add("mnemonic", "Mnemonic", "助记词")
+	add("hdPath", "HD Path", "HD路径")
+	add("bip39Passphrase", "BIP39 Passphrase (Optional)", "BIP39口令（可选）")
+	add("mnemonicWords", "Mnemonic Words", "助记词单词数")
+	add("mnemonicLanguage", "Mnemonic Language", "助记词语言")
+	add("mnemonicAssist", "Assistant", "输入助手")
+	add("wordsEntered", "%d words entered", "已输入%d个单词")
+	add("mnemonicOK", "the words and the checksum are valid", "单词和校验和均有效")
+	add("completions", "Completions: ", "补全：")
+	add("didYouMean", "did you mean: ", "您是否想输入：")
+	add("checksumFixes", "Replacing one of these words would make the checksum valid:", "替换以下任一单词可使校验和有效：")
+	add("checksumFix", "  word %d with \"%s\"", "  第%d个单词换为\"%s\"")
+	add("moreChecksumFixes", "  and %d more", "  另有%d个")
+	add("lang_english", "English", "英语")
+	add("lang_chinese_simplified", "Chinese (Simplified)", "简体中文")
+	add("lang_chinese_traditional", "Chinese (Traditional)", "繁体中文")
+	add("lang_japanese", "Japanese", "日语")
+	add("lang_korean", "Korean", "韩语")
+	add("lang_spanish", "Spanish", "西班牙语")
+	add("lang_french", "French", "法语")
+	add("lang_italian", "Italian", "意大利语")
+	add("lang_czech", "Czech", "捷克语")
+	add("userEntropy", "User Entropy", "用户熵")
+	add("entropyNone", "None", "无")
+	add("entropyDice", "Dice Rolls (1-6)", "掷骰子（1-6）")
+	add("entropyCoins", "Coin Flips (H/T)", "抛硬币（H/T）")
+	add("entropyHex", "Hex Digits", "十六进制数字")
+	add("entropyInput", "Entropy Input", "熵输入")
+	add("weakUserEntropy", "The user entropy has about %.0f bits, which is less than the %d bits of the mnemonic. It is still mixed with the system randomness.",
+		"用户熵约有%.0f位，少于助记词的%d位。它仍然会和系统随机数混合。")
+	add("systemEntropy", "System Entropy", "系统熵")
+	add("candidateIndex", "Candidate Index", "候选序号")
+	add("bip39PassphraseIs", "BIP39 passphrase: %s", "BIP39口令：%s")
 	add("progress", "Progress", "进展")
 	add("caline1", "Please enter the prefix and suffix of your desired address below.",
 		"请在下方输入您所期待的地址的前缀和后缀。")
@@ -90,20 +379,53 @@ func init() {
 		"加密口令将被用来加密存储在磁盘上的私钥。")
 	add("caline7", "Memo is some information to remind yourself what's the usage of this account.",
 		"备忘一栏用来填写一些信息，用来提醒你自己这个账户的用途是什么")
+	add("caline8", "HD path derives the private key from the mnemonic, change its account or index to get more accounts from one mnemonic.",
+		"HD路径用来从助记词推导出私钥，修改其中的account或index可以从一个助记词得到更多账户")
+	add("caline9", "BIP39 passphrase is mixed with the mnemonic to derive the key, you must back it up with the mnemonic if it is not empty.",
+		"BIP39口令会和助记词一起用来推导私钥，如果它不为空，您必须将它和助记词一起备份")
+	add("caline10", "A mnemonic of 12 words is easier to back up, and one of 24 words is stronger.",
+		"12个单词的助记词更容易备份，24个单词的助记词则更安全")
+	add("caline11", "User entropy such as dice rolls is mixed with the system randomness, keep the system entropy and the candidate index shown below to reproduce the mnemonic.",
+		"骰子点数等用户熵会和系统随机数混合，请保存下方显示的系统熵和候选序号，以便重现助记词")
 	add("origMsg", "Original Message", "原始消息")
 	add("readableMsg", "Readable Message", "可读的消息")
 	add("mismatchPassphrase", "The two passphrases are mismatched", "输入的两个口令不一致")
 	add("emptyMemo", "The Memo can not be empty", "输入的备忘信息不可以为空")
+	add("emptyPassphrase", "The passphrase can not be empty", "口令不可以为空")
 	add("sureToExit", "Are you sure to exit this program?", "您确认要退出本程序吗？")
 	add("exit?", "Exit?", "退出？")
+	add("restoreBackup?", "Restore?", "恢复？")
+	add("sureToRestore", "Are you sure to replace the current keybase with %s? The current one will be kept as a backup.",
+		"您确认要用%s替换当前的私钥数据库吗？当前的私钥数据库会被保存为一个备份。")
+	add("successRestore", "Success in restoring the keybase", "私钥数据库已成功恢复")
 	add("noAccYet", "The Keybase has no accounts yet.", "私钥数据库中尚未创建任何账户。")
 	add("noSelAcc", "No account was selected in the list.", "您尚未选中列表中的任一账户。")
+	add("selOneAcc", "Please select only one account in the list for this operation.", "此操作只能选中列表中的一个账户。")
 	add("mnemonicOf", "The mnemonics of %s", "%的助记词")
 	add("qrCodeOfAddr", "The QRCode of \"%s\"", "\"%s\"的二维码")
 	add("qrCodeOfAddrBelow", "Below is the QRCode of \"%s\"", "下面是\"%s\"的二维码")
 	add("successCA", "Success in creating an account: ", "账户创建成功：")
 	add("successCopy", "Success in copying address", "账户地址已成功拷贝")
 	add("successSign", "Success in signing", "签名成功")
+	add("noValidMsg", "There is no valid message to sign", "没有可以签名的有效消息")
+	add("errNoSuchAccount", "No such account", "没有这个账户")
+	add("errBadPassphrase", "Wrong passphrase", "口令错误")
+	add("errDecryptFailed", "Failed to decrypt: wrong key or corrupted data", "解密失败：密钥错误或数据已损坏")
+	add("errInvalidMnemonic", "Invalid mnemonic", "无效的助记词")
+	add("errCorruptedAccount", "The account's data in the keybase is corrupted", "私钥数据库中该账户的数据已损坏")
+	add("errUnsupportedKdf", "The account is encrypted with an unsupported method", "该账户使用了不支持的加密方法")
+	add("errInvalidKeybase", "Invalid keybase file", "无效的私钥数据库文件")
+	add("errNotPlainFile", "Not a plain file", "不是一个普通文件")
+	add("errUnsupportedScheme", "Unsupported keybase location, only file://, dir:// and mem:// are supported",
+		"不支持的私钥数据库位置，只支持file://、dir://和mem://")
+	add("errBadKeybasePassphrase", "Wrong passphrase of the keybase", "私钥数据库的口令错误")
+	add("errKeybasePassphraseNeeded", "The whole keybase is encrypted, its passphrase is needed to open it",
+		"整个私钥数据库是加密的，需要输入它的口令才能打开")
+	add("errFormatVersion", "The keybase's format version is %d, but this program only supports up to %d. Please upgrade it.",
+		"私钥数据库的格式版本是%d，但本程序最高只支持%d，请升级本程序。")
+	add("errUnknownTxType", "Invalid transaction type", "无效的交易类型")
+	add("errInvalidVoteOption", "Invalid vote option", "无效的投票选项")
+	add("errInvalidRawTx", "Invalid raw transaction, it must be a list of two strings", "无效的原始交易，它必须是包含两个字符串的列表")
 	add("notHaveAcc", "This Keybase does not have the required account: ", "此私钥数据库并未保存签名所需要的账户：")
 	add("seeSignQRBelow",
 	"The signed result is ready. You can scan it from the below QRCode",
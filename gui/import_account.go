@@ -0,0 +1,402 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lxn/walk"
+	. "github.com/lxn/walk/declarative"
+
+	"github.com/coinexchain/ColdWallet.win/keykeeper"
+)
+
+// ask user for a mnemonic held on paper and import it as an account
+func (mw *AppMainWindow) importMnemonicTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	ShowImportMnemonicDialog(mw, func(memo string, key keykeeper.MnemonicKey, pass string) error {
+		acc, err := keykeeper.ImportMnemonic(memo, key, pass)
+		if err != nil {
+			return err
+		}
+		walk.MsgBox(MainWin, T("success"), T("successImport")+acc.Address, walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+		return nil
+	})
+}
+
+// prompt user to enter a mnemonic with its memo, HD path, BIP39 passphrase and passphrase.
+// The dialog is kept open when okCallback returns an error, so user can correct the input.
+func ShowImportMnemonicDialog(owner walk.Form, okCallback func(memo string, key keykeeper.MnemonicKey, pass string) error) {
+	var dlg *walk.Dialog
+	var okPB, cancelPB *walk.PushButton
+	var mnemonicTextEdit, assistTextEdit *walk.TextEdit
+	var memoLineEdit, hdPathLineEdit, bip39PassLineEdit, pass1LineEdit, pass2LineEdit *walk.LineEdit
+	var languageComboBox *walk.ComboBox
+	languages := keykeeper.AvailableLanguages()
+	language := func() string {
+		if i := languageComboBox.CurrentIndex(); i >= 0 {
+			return languages[i]
+		}
+		return ""
+	}
+	// diagnose the mnemonic as it is typed, to complete the words and catch the typos early
+	updateAssist := func() {
+		if mnemonicTextEdit == nil || assistTextEdit == nil {
+			return
+		}
+		d, err := keykeeper.DiagnoseMnemonic(language(), mnemonicTextEdit.Text())
+		if err != nil {
+			assistTextEdit.SetText(TErr(err))
+			return
+		}
+		assistTextEdit.SetText(diagnosisText(d))
+	}
+
+	var dialog = Dialog{}
+	dialog.AssignTo = &dlg
+	dialog.Title = T("importMnemonic")
+	dialog.MinSize = Size{500, 450}
+	dialog.Layout = VBox{}
+	dialog.DefaultButton = &okPB
+	dialog.CancelButton = &cancelPB
+
+	childrens := []Widget{
+		Label{Text: T("imline1")},
+		Composite{
+			Layout: Grid{Columns: 2},
+			Children: []Widget{
+				Label{Text: T("mnemonicLanguage")},
+				ComboBox{
+					AssignTo:              &languageComboBox,
+					Model:                 languageNames(languages),
+					CurrentIndex:          0,
+					OnCurrentIndexChanged: updateAssist,
+				},
+				Label{Text: T("mnemonic")},
+				TextEdit{
+					AssignTo:      &mnemonicTextEdit,
+					OnTextChanged: updateAssist,
+				},
+				Label{Text: T("mnemonicAssist")},
+				TextEdit{
+					AssignTo: &assistTextEdit,
+					ReadOnly: true,
+					VScroll:  true,
+				},
+				Label{Text: T("memo")},
+				LineEdit{AssignTo: &memoLineEdit},
+				Label{Text: T("hdPath")},
+				LineEdit{
+					AssignTo: &hdPathLineEdit,
+					Text:     keykeeper.DefaultHDPath.String(),
+				},
+				Label{Text: T("bip39Passphrase")},
+				LineEdit{AssignTo: &bip39PassLineEdit},
+				Label{Text: T("encryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass1LineEdit,
+					PasswordMode: true,
+				},
+				Label{Text: T("retypeEncryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass2LineEdit,
+					PasswordMode: true,
+				},
+			},
+		},
+		Composite{
+			Layout: HBox{},
+			Children: []Widget{
+				HSpacer{},
+				PushButton{
+					AssignTo: &okPB,
+					Text:     T("ok"),
+					OnClicked: func() {
+						pass1 := pass1LineEdit.Text()
+						pass2 := pass2LineEdit.Text()
+						if pass1 != pass2 {
+							walk.MsgBox(MainWin, T("error!"), T("mismatchPassphrase"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						memo := memoLineEdit.Text()
+						if len(memo) == 0 {
+							walk.MsgBox(MainWin, T("error!"), T("emptyMemo"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						hdPath, err := keykeeper.ParseHDPath(hdPathLineEdit.Text())
+						if err == nil {
+							key := keykeeper.MnemonicKey{
+								Mnemonic:        mnemonicTextEdit.Text(),
+								Path:            hdPath,
+								BIP39Passphrase: bip39PassLineEdit.Text(),
+								Language:        language(),
+							}
+							err = okCallback(memo, key, pass1)
+						}
+						if err != nil {
+							walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						dlg.Accept()
+					},
+				},
+				PushButton{
+					AssignTo:  &cancelPB,
+					Text:      T("cancel"),
+					OnClicked: func() { dlg.Cancel() },
+				},
+			},
+		},
+	}
+	dialog.Children = childrens
+	dialog.Run(owner)
+}
+
+// ask user for a raw private key in hex, in the armor of `cetcli keys export` or in a Web3 keystore JSON,
+// and import it as an account
+func (mw *AppMainWindow) importPrivKeyTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	ShowImportPrivKeyDialog(mw, func(memo, key, armorPass, pass string) error {
+		var acc keykeeper.AccountInfo
+		var err error
+		switch {
+		case strings.Contains(key, "-----BEGIN"):
+			acc, err = keykeeper.ImportArmoredPrivKey(memo, key, armorPass, pass)
+		case strings.HasPrefix(strings.TrimSpace(key), "{"):
+			acc, err = keykeeper.ImportKeystore(memo, []byte(key), armorPass, pass)
+		default:
+			acc, err = keykeeper.ImportPrivKeyHex(memo, key, pass)
+		}
+		if err != nil {
+			return err
+		}
+		walk.MsgBox(MainWin, T("success"), T("successImport")+acc.Address, walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+		return nil
+	})
+}
+
+// prompt user to enter a private key with its memo and passphrase.
+// The dialog is kept open when okCallback returns an error, so user can correct the input.
+func ShowImportPrivKeyDialog(owner walk.Form, okCallback func(memo, key, armorPass, pass string) error) {
+	var dlg *walk.Dialog
+	var okPB, cancelPB *walk.PushButton
+	var keyTextEdit *walk.TextEdit
+	var memoLineEdit, armorPassLineEdit, pass1LineEdit, pass2LineEdit *walk.LineEdit
+
+	var dialog = Dialog{}
+	dialog.AssignTo = &dlg
+	dialog.Title = T("importPrivKey")
+	dialog.MinSize = Size{500, 350}
+	dialog.Layout = VBox{}
+	dialog.DefaultButton = &okPB
+	dialog.CancelButton = &cancelPB
+
+	childrens := []Widget{
+		Label{Text: T("ipkline1")},
+		Composite{
+			Layout: Grid{Columns: 2},
+			Children: []Widget{
+				Label{Text: T("privKey")},
+				TextEdit{AssignTo: &keyTextEdit},
+				Label{Text: T("armorPassphrase")},
+				LineEdit{
+					AssignTo: &armorPassLineEdit,
+					PasswordMode: true,
+				},
+				Label{Text: T("memo")},
+				LineEdit{AssignTo: &memoLineEdit},
+				Label{Text: T("encryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass1LineEdit,
+					PasswordMode: true,
+				},
+				Label{Text: T("retypeEncryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass2LineEdit,
+					PasswordMode: true,
+				},
+			},
+		},
+		Composite{
+			Layout: HBox{},
+			Children: []Widget{
+				HSpacer{},
+				PushButton{
+					AssignTo: &okPB,
+					Text:     T("ok"),
+					OnClicked: func() {
+						pass1 := pass1LineEdit.Text()
+						pass2 := pass2LineEdit.Text()
+						if pass1 != pass2 {
+							walk.MsgBox(MainWin, T("error!"), T("mismatchPassphrase"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						memo := memoLineEdit.Text()
+						if len(memo) == 0 {
+							walk.MsgBox(MainWin, T("error!"), T("emptyMemo"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						err := okCallback(memo, keyTextEdit.Text(), armorPassLineEdit.Text(), pass1)
+						if err != nil {
+							walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						dlg.Accept()
+					},
+				},
+				PushButton{
+					AssignTo:  &cancelPB,
+					Text:      T("cancel"),
+					OnClicked: func() { dlg.Cancel() },
+				},
+			},
+		},
+	}
+	dialog.Children = childrens
+	dialog.Run(owner)
+}
+
+// select a cetcli home directory, and import the chosen keys from its keyring
+func (mw *AppMainWindow) importKeyringTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	dlg := new(walk.FileDialog)
+	dlg.Title = T("selectKeyringHome")
+	ok, err := dlg.ShowBrowseFolder(mw)
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	if !ok {
+		return
+	}
+	kr, err := keykeeper.OpenKeyring(dlg.FilePath)
+	var keyringKeys []keykeeper.KeyringKey
+	if err == nil {
+		keyringKeys, err = kr.List()
+	}
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	var items []string
+	for _, k := range keyringKeys {
+		if k.Importable() {
+			items = append(items, k.Name)
+		}
+	}
+	if len(items) == 0 {
+		walk.MsgBox(MainWin, T("error!"), T("noKeyringKeys"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	ShowImportKeyringDialog(mw, items, func(names []string, pass string) error {
+		passphraseOf := func(name string) (string, error) {
+			var passphrase string
+			entered := false
+			ShowPassphraseDialogWithTitle(MainWin, fmt.Sprintf(T("enterKeyringPassphrase"), name), func(pass string) {
+				passphrase = pass
+				entered = true
+			})
+			if !entered {
+				return "", errors.New(T("canceled"))
+			}
+			return passphrase, nil
+		}
+		accs, err := keykeeper.ImportFromKeyring(kr, names, passphraseOf, pass)
+		if err != nil {
+			return err
+		}
+		addrs := make([]string, len(accs))
+		for i, acc := range accs {
+			addrs[i] = acc.Address + ": " + acc.Memo
+		}
+		walk.MsgBox(MainWin, T("success"), T("successImportKeyring")+"\r\n"+strings.Join(addrs, "\r\n"),
+			walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+		return nil
+	})
+}
+
+// prompt user to choose some of the keys listed in items, and the passphrase to encrypt them in the keybase.
+// The dialog is kept open when okCallback returns an error, so user can correct the input.
+func ShowImportKeyringDialog(owner walk.Form, items []string, okCallback func(names []string, pass string) error) {
+	var dlg *walk.Dialog
+	var okPB, cancelPB *walk.PushButton
+	var keysListBox *walk.ListBox
+	var pass1LineEdit, pass2LineEdit *walk.LineEdit
+
+	var dialog = Dialog{}
+	dialog.AssignTo = &dlg
+	dialog.Title = T("importKeyring")
+	dialog.MinSize = Size{500, 350}
+	dialog.Layout = VBox{}
+	dialog.DefaultButton = &okPB
+	dialog.CancelButton = &cancelPB
+
+	childrens := []Widget{
+		Label{Text: T("ikline1")},
+		ListBox{
+			AssignTo:       &keysListBox,
+			Model:          items,
+			MultiSelection: true,
+		},
+		Composite{
+			Layout: Grid{Columns: 2},
+			Children: []Widget{
+				Label{Text: T("encryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass1LineEdit,
+					PasswordMode: true,
+				},
+				Label{Text: T("retypeEncryptPassphrase")},
+				LineEdit{
+					AssignTo: &pass2LineEdit,
+					PasswordMode: true,
+				},
+			},
+		},
+		Composite{
+			Layout: HBox{},
+			Children: []Widget{
+				HSpacer{},
+				PushButton{
+					AssignTo: &okPB,
+					Text:     T("ok"),
+					OnClicked: func() {
+						pass1 := pass1LineEdit.Text()
+						pass2 := pass2LineEdit.Text()
+						if pass1 != pass2 {
+							walk.MsgBox(MainWin, T("error!"), T("mismatchPassphrase"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						var names []string
+						for _, idx := range keysListBox.SelectedIndexes() {
+							names = append(names, items[idx])
+						}
+						if len(names) == 0 {
+							walk.MsgBox(MainWin, T("error!"), T("noSelAcc"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						err := okCallback(names, pass1)
+						if err != nil {
+							walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+							return
+						}
+						dlg.Accept()
+					},
+				},
+				PushButton{
+					AssignTo:  &cancelPB,
+					Text:      T("cancel"),
+					OnClicked: func() { dlg.Cancel() },
+				},
+			},
+		},
+	}
+	dialog.Children = childrens
+	dialog.Run(owner)
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"github.com/cosmos/cosmos-sdk/crypto/keys"
 	"github.com/lxn/walk"
 	. "github.com/lxn/walk/declarative"
@@ -51,8 +53,9 @@ func newListAccountsPage(parent walk.Container, _ interface{}) (Page, error) {
 		Layout:   VBox{},
 		Children: []Widget{
 			ListBox{
-				AssignTo: &p.accountListBox,
-				Model:    p.model,
+				AssignTo:       &p.accountListBox,
+				Model:          p.model,
+				MultiSelection: true,
 			},
 			Composite{
 				Layout:   HBox{},
@@ -75,6 +78,24 @@ func newListAccountsPage(parent walk.Container, _ interface{}) (Page, error) {
 							runShowMnemonic(p)
 						},
 					},
+					PushButton{
+						Text: T("showPrivKey"),
+						OnClicked: func() {
+							runShowPrivKey(p)
+						},
+					},
+					PushButton{
+						Text: T("exportKeys"),
+						OnClicked: func() {
+							runExportKeys(p)
+						},
+					},
+					PushButton{
+						Text: T("exportKeystore"),
+						OnClicked: func() {
+							runExportKeystore(p)
+						},
+					},
 					PushButton{
 						Text: T("showAddrQRCode"),
 						OnClicked: func() {
@@ -110,19 +131,45 @@ func getSelectedAddr(p *ListAccountsPage) (item string, ok bool) {
 		walk.MsgBox(MainWin, T("error!"), T("noAccYet"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 		return "", false
 	}
+	// the actions on a single account must not pick the current one out of a selection of several
+	if len(p.accountListBox.SelectedIndexes()) > 1 {
+		walk.MsgBox(MainWin, T("error!"), T("selOneAcc"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return "", false
+	}
 	idx := p.accountListBox.CurrentIndex()
 	if idx == -1 || len(p.model.items[idx]) == 0 {
 		walk.MsgBox(MainWin, T("error!"), T("noSelAcc"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 		return "", false
 	}
-	item = p.model.items[idx]
-	splitterPos := strings.Index(item, " - ") //bech32 address is before splitter
+	return addrOfItem(p.model.items[idx])
+}
+
+// addrOfItem returns the address of a list item, which is "<address>: <memo>" as made by GetStringItems.
+func addrOfItem(item string) (string, bool) {
+	splitterPos := strings.Index(item, ": ") //bech32 address is before splitter
 	if splitterPos == -1 {
 		return "", false
 	}
 	return item[:splitterPos], true
 }
 
+// getSelectedAddrs returns the addresses of all the selected accounts, for the operations on a selection
+func getSelectedAddrs(p *ListAccountsPage) (addrs []string, ok bool) {
+	if !MainWin.CheckKBOpened() {
+		return nil, false
+	}
+	for _, idx := range p.accountListBox.SelectedIndexes() {
+		if addr, ok := addrOfItem(p.model.items[idx]); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+	if len(addrs) == 0 {
+		walk.MsgBox(MainWin, T("error!"), T("noSelAcc"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return nil, false
+	}
+	return addrs, true
+}
+
 func runDeleteAccount(p *ListAccountsPage) {
 	addr, ok := getSelectedAddr(p)
 	if !ok {
@@ -131,7 +178,7 @@ func runDeleteAccount(p *ListAccountsPage) {
 	ShowPassphraseDialog(MainWin, func(pass string) {
 		err := keykeeper.DeleteAccount(addr, pass)
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 			return
 		}
 	})
@@ -145,7 +192,7 @@ func runChangePassphrase(p *ListAccountsPage) {
 	ShowChangePassphraseDialog(MainWin, func(oldPass, newPass string) {
 		err := keykeeper.ChangePassphrase(addr, oldPass, newPass)
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 			return
 		}
 	})
@@ -157,13 +204,121 @@ func runShowMnemonic(p *ListAccountsPage) {
 		return
 	}
 	ShowPassphraseDialog(MainWin, func(pass string) {
-		mnemonic, err := keykeeper.GetMnemonic(addr, pass)
+		key, err := keykeeper.GetMnemonicKey(addr, pass)
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 			return
 		}
+		text := key.Mnemonic
+		if key.BIP39Passphrase != "" {
+			text += "\r\n\r\n" + fmt.Sprintf(T("bip39PassphraseIs"), key.BIP39Passphrase)
+		}
 		walk.MsgBox(MainWin, fmt.Sprintf(T("mnemonicOf"), addr),
-			mnemonic, walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			text, walk.MsgBoxIconError|walk.MsgBoxApplModal)
+	})
+}
+
+func runShowPrivKey(p *ListAccountsPage) {
+	addr, ok := getSelectedAddr(p)
+	if !ok {
+		return
+	}
+	ShowPassphraseDialog(MainWin, func(pass string) {
+		privKey, err := keykeeper.ExportPrivKeyHex(addr, pass)
+		if err != nil {
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
+		}
+		walk.MsgBox(MainWin, fmt.Sprintf(T("privKeyOf"), addr),
+			privKey, walk.MsgBoxIconError|walk.MsgBoxApplModal)
+	})
+}
+
+// export the private keys of the selected accounts into a directory, in the armor read by `cetcli keys import`
+func runExportKeys(p *ListAccountsPage) {
+	addrs, ok := getSelectedAddrs(p)
+	if !ok {
+		return
+	}
+	var exportPass string
+	entered := false
+	ShowNewPassphraseDialog(MainWin, T("exportPassphrase"), func(pass string) {
+		exportPass = pass
+		entered = true
+	})
+	if !entered {
+		return
+	}
+	dlg := new(walk.FileDialog)
+	dlg.Title = T("selectExportDir")
+	if len(MainWin.prevDir) != 0 {
+		dlg.InitialDirPath = MainWin.prevDir
+	}
+	ok, err := dlg.ShowBrowseFolder(MainWin)
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	if !ok {
+		return
+	}
+	passphraseOf := func(addr string) (string, error) {
+		if pass, ok := keykeeper.GetCachedPassphrase(addr); ok {
+			return pass, nil
+		}
+		var passphrase string
+		entered := false
+		ShowPassphraseDialogWithTitle(MainWin, fmt.Sprintf(T("enterPassphraseOf"), addr), func(pass string) {
+			passphrase = pass
+			entered = true
+		})
+		if !entered {
+			return "", errors.New(T("canceled"))
+		}
+		return passphrase, nil
+	}
+	fnames, err := keykeeper.ExportArmoredPrivKeys(dlg.FilePath, addrs, passphraseOf, exportPass)
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	walk.MsgBox(MainWin, T("success"), T("successExport")+"\r\n"+strings.Join(fnames, "\r\n"),
+		walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+}
+
+// export the private key of the selected account into a Web3 keystore JSON file
+func runExportKeystore(p *ListAccountsPage) {
+	addr, ok := getSelectedAddr(p)
+	if !ok {
+		return
+	}
+	ShowPassphraseDialog(MainWin, func(pass string) {
+		ShowNewPassphraseDialog(MainWin, T("keystorePassphrase"), func(keystorePass string) {
+			keystore, err := keykeeper.ExportKeystore(addr, pass, keystorePass)
+			if err != nil {
+				walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+				return
+			}
+			dlg := new(walk.FileDialog)
+			dlg.Title = T("selectKeystoreFile")
+			dlg.Filter = "json files (*.json)|*.json|All files (*.*)|*.*"
+			dlg.FilePath = addr + ".json"
+			if len(MainWin.prevDir) != 0 {
+				dlg.InitialDirPath = MainWin.prevDir
+			}
+			ok, err := dlg.ShowSave(MainWin)
+			if err == nil && ok {
+				err = ioutil.WriteFile(dlg.FilePath, keystore, 0600)
+			}
+			if err != nil {
+				walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+				return
+			}
+			if ok {
+				walk.MsgBox(MainWin, T("success"), T("successExportKeystore")+dlg.FilePath,
+					walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+			}
+		})
 	})
 }
 
@@ -177,7 +332,7 @@ func runShowAddrQRCode(p *ListAccountsPage) {
 		fmt.Sprintf(T("qrCodeOfAddrBelow"), addr),
 	)
 	if err != nil {
-		walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 	}
 }
 
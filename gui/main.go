@@ -3,10 +3,13 @@ package main
 import (
 	//"fmt"
 	"bytes"
+	"errors"
 	"encoding/base64"
 	"fmt"
 	"image/png"
+	"os"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/lxn/walk"
@@ -38,7 +41,7 @@ func (mw *AppMainWindow) aboutActionTriggered() {
 	walk.MsgBox(mw, T("aboutTitle"), T("aboutContent"), walk.MsgBoxOK|walk.MsgBoxIconInformation)
 }
 
-func (mw *AppMainWindow) openActionTriggered(withCreation bool) {
+func (mw *AppMainWindow) openActionTriggered(withCreation bool, mode keykeeper.OpenMode) {
 	dlg := new(walk.FileDialog)
 	dlg.Filter = "json files (*.json)|*.json|All files (*.*)|*.*"
 	dlg.FilterIndex = 1
@@ -58,7 +61,7 @@ func (mw *AppMainWindow) openActionTriggered(withCreation bool) {
 	}
 
 	if err != nil {
-		walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 		return
 	}
 	if !ok {
@@ -69,14 +72,146 @@ func (mw *AppMainWindow) openActionTriggered(withCreation bool) {
 	if !strings.HasSuffix(dlg.FilePath, ".json") {
 		fname = fname + ".json"
 	}
-	err = keykeeper.OpenKeybase(fname)
+	// remember the keybase passphrase, so it is not asked twice when a tampered keybase is accepted
+	var keybasePass *string
+	prompt := func() (string, error) {
+		if keybasePass != nil {
+			return *keybasePass, nil
+		}
+		pass, err := promptKeybasePassphrase()
+		if err == nil {
+			keybasePass = &pass
+		}
+		return pass, err
+	}
+	err = keykeeper.OpenKeybase(fname, keykeeper.OpenOptions{Mode: mode, Prompt: prompt})
+	if errors.Is(err, keykeeper.ErrKeybaseLocked) {
+		res := walk.MsgBox(MainWin, T("warn"), TErr(err)+"\r\n\r\n"+T("openReadOnly?"),
+			walk.MsgBoxYesNo|walk.MsgBoxIconWarning|walk.MsgBoxApplModal)
+		if res != walk.DlgCmdYes {
+			return
+		}
+		mode = keykeeper.OpenReadOnly
+		err = keykeeper.OpenKeybase(fname, keykeeper.OpenOptions{Mode: mode, Prompt: prompt})
+	}
+	var tamperErr *keykeeper.TamperError
+	if errors.As(err, &tamperErr) {
+		res := walk.MsgBox(MainWin, T("warn"), TErr(err)+"\r\n\r\n"+T("acceptTampered?"),
+			walk.MsgBoxYesNo|walk.MsgBoxIconWarning|walk.MsgBoxApplModal)
+		if res != walk.DlgCmdYes {
+			return
+		}
+		err = keykeeper.OpenKeybase(fname, keykeeper.OpenOptions{Mode: mode, Prompt: prompt, AcceptTampered: true})
+	}
 	if err != nil {
-		walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 		return
 	}
 
-	mw.updateTitle(fname)
+	if mode == keykeeper.OpenReadOnly {
+		mw.updateTitle(fname + " [" + T("readOnly") + "]")
+	} else {
+		mw.updateTitle(fname)
+	}
 	mw.prevDir, _ = path.Split(fname)
+	// without a keybase passphrase there is no integrity manifest, and removing it from a protected
+	// keybase can not be detected, so the user is warned at every open
+	if !keykeeper.HasKeybasePassphrase() {
+		walk.MsgBox(MainWin, T("warn"), T("unprotectedKeybase"), walk.MsgBoxIconWarning|walk.MsgBoxApplModal)
+	}
+}
+
+func (mw *AppMainWindow) restoreBackupTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	dlg := new(walk.FileDialog)
+	dlg.Filter = "backup files (*.bak)|*.bak"
+	dlg.Title = T("selectBackup")
+	if len(mw.prevDir) != 0 {
+		dlg.InitialDirPath = mw.prevDir
+	}
+	ok, err := dlg.ShowOpen(mw)
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	if !ok {
+		return
+	}
+	res := walk.MsgBox(MainWin, T("restoreBackup?"), fmt.Sprintf(T("sureToRestore"), dlg.FilePath),
+		walk.MsgBoxYesNo|walk.MsgBoxIconQuestion|walk.MsgBoxApplModal)
+	if res != walk.DlgCmdYes {
+		return
+	}
+	err = keykeeper.RestoreKeybaseBackup(dlg.FilePath, promptKeybasePassphrase)
+	if err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	walk.MsgBox(MainWin, T("success"), T("successRestore"), walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+}
+
+func (mw *AppMainWindow) sealActionTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	if keykeeper.KeybaseSealed() {
+		walk.MsgBox(MainWin, T("error!"), T("alreadySealed"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	doSeal := func(pass string) {
+		err := keykeeper.SealKeybase(pass)
+		if err != nil {
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
+		}
+		walk.MsgBox(MainWin, T("success"), T("successSeal"), walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+	}
+	if keykeeper.HasKeybasePassphrase() {
+		ShowPassphraseDialogWithTitle(MainWin, T("enterKeybasePassphrase"), doSeal)
+	} else {
+		ShowNewPassphraseDialog(MainWin, T("sealKeybase"), doSeal)
+	}
+}
+
+func (mw *AppMainWindow) keybasePassphraseTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	doSet := func(oldPass, newPass string) {
+		err := keykeeper.SetKeybasePassphrase(oldPass, newPass)
+		if err != nil {
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
+		}
+		walk.MsgBox(MainWin, T("success"), T("successKeybasePassphrase"), walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+	}
+	if keykeeper.HasKeybasePassphrase() {
+		ShowChangePassphraseDialog(MainWin, doSet)
+	} else {
+		ShowNewPassphraseDialog(MainWin, T("keybasePassphrase"), func(newPass string) {
+			doSet("", newPass)
+		})
+	}
+}
+
+func (mw *AppMainWindow) unsealActionTriggered() {
+	if !mw.CheckKBOpened() {
+		return
+	}
+	if !keykeeper.KeybaseSealed() {
+		walk.MsgBox(MainWin, T("error!"), T("notSealed"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
+	ShowPassphraseDialogWithTitle(MainWin, T("enterKeybasePassphrase"), func(pass string) {
+		err := keykeeper.UnsealKeybase(pass)
+		if err != nil {
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
+		}
+		walk.MsgBox(MainWin, T("success"), T("successUnseal"), walk.MsgBoxIconInformation|walk.MsgBoxApplModal)
+	})
 }
 
 func (mw *AppMainWindow) scanQRCode() {
@@ -111,11 +246,31 @@ func main() {
 				Items: []MenuItem{
 					Action{
 						Text:        T("open"),
-						OnTriggered: func() { mw.openActionTriggered(false) },
+						OnTriggered: func() { mw.openActionTriggered(false, keykeeper.OpenReadWrite) },
+					},
+					Action{
+						Text:        T("openReadOnly"),
+						OnTriggered: func() { mw.openActionTriggered(false, keykeeper.OpenReadOnly) },
 					},
 					Action{
 						Text:        T("create&open"),
-						OnTriggered: func() { mw.openActionTriggered(true) },
+						OnTriggered: func() { mw.openActionTriggered(true, keykeeper.OpenReadWrite) },
+					},
+					Action{
+						Text:        T("restoreBackup"),
+						OnTriggered: func() { mw.restoreBackupTriggered() },
+					},
+					Action{
+						Text:        T("keybasePassphrase"),
+						OnTriggered: func() { mw.keybasePassphraseTriggered() },
+					},
+					Action{
+						Text:        T("sealKeybase"),
+						OnTriggered: func() { mw.sealActionTriggered() },
+					},
+					Action{
+						Text:        T("unsealKeybase"),
+						OnTriggered: func() { mw.unsealActionTriggered() },
 					},
 					Separator{},
 					Action{
@@ -131,6 +286,23 @@ func main() {
 					},
 				},
 			},
+			Menu{
+				Text: T("account"),
+				Items: []MenuItem{
+					Action{
+						Text:        T("importMnemonic"),
+						OnTriggered: func() { mw.importMnemonicTriggered() },
+					},
+					Action{
+						Text:        T("importPrivKey"),
+						OnTriggered: func() { mw.importPrivKeyTriggered() },
+					},
+					Action{
+						Text:        T("importKeyring"),
+						OnTriggered: func() { mw.importKeyringTriggered() },
+					},
+				},
+			},
 			Action{
 				Text:        T("scanQRCode"),
 				OnTriggered: func() {
@@ -189,6 +361,18 @@ func main() {
 		walk.App().Exit(0)
 	}
 
+	if err := keykeeper.CheckSystemRandom(); err != nil {
+		walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		walk.App().Exit(1)
+	}
+
+	// the wordlists of the languages other than English are the files of the BIP39 repository in "wordlists"
+	if exe, err := os.Executable(); err == nil {
+		if _, err := keykeeper.LoadWordlists(filepath.Join(filepath.Dir(exe), "wordlists")); err != nil {
+			walk.MsgBox(MainWin, T("warn"), TErr(err), walk.MsgBoxIconWarning|walk.MsgBoxApplModal)
+		}
+	}
+
 	font := mw.Font()
 	fmt.Printf("%#v\n", font)
 	newFont, err := walk.NewFont(font.Family(), font.PointSize()+1, font.Style())
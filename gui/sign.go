@@ -83,7 +83,8 @@ func parseOrigMsg(p *SignPage) {
 	msg, err := msg.ParseJson(jsonStr)
 	if err != nil {
 		p.parsedMsg = nil
-		p.readableTextEdit.SetText(err.Error())
+		p.readableTextEdit.SetText(TErr(err))
+		return
 	}
 	p.parsedMsg = &msg
 }
@@ -92,6 +93,10 @@ func runSign(p *SignPage) {
 	if !MainWin.CheckKBOpened() {
 		return
 	}
+	if p.parsedMsg == nil {
+		walk.MsgBox(MainWin, T("error!"), T("noValidMsg"), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+		return
+	}
 	signer := p.parsedMsg.GetSigner()
 	if !keykeeper.HasAccount(signer) {
 		walk.MsgBox(MainWin, T("error!"), T("notHaveAcc")+signer, walk.MsgBoxIconError|walk.MsgBoxApplModal)
@@ -102,12 +107,12 @@ func runSign(p *SignPage) {
 	doSign := func(passphrase string) {
 		signedResult, err := keykeeper.Sign(signer, passphrase, signBytes)
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 			return
 		}
 		err = ShowQRCodeDialog(MainWin, signedResult, T("successSign"), T("seeSignQRBelow"))
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
 		}
 	}
 
@@ -120,7 +125,8 @@ func runSign(p *SignPage) {
 	ShowPassphraseDialog(MainWin, func(passphrase string) {
 		err := keykeeper.AddCachedPassphrase(signer, passphrase)
 		if err != nil {
-			walk.MsgBox(MainWin, T("error!"), err.Error(), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			walk.MsgBox(MainWin, T("error!"), TErr(err), walk.MsgBoxIconError|walk.MsgBoxApplModal)
+			return
 		}
 		doSign(passphrase)
 	})
@@ -0,0 +1,227 @@
+package keykeeper
+
+import (
+	"crypto/sha256"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// MaxSuggestionDistance is the largest edit distance of the words suggested for an unknown word
+	MaxSuggestionDistance = 2
+	// MaxSuggestions limits the suggestions for each unknown word
+	MaxSuggestions = 5
+	// MaxCompletions limits the completions of the word being typed
+	MaxCompletions = 10
+)
+
+// WordCheck tells whether a word of a mnemonic being entered is in the wordlist
+type WordCheck struct {
+	// Position is 1-based
+	Position int
+	Word     string
+	Known    bool
+	// Partial means the word is still being typed and some words in the list start with it
+	Partial bool
+	// Suggestions are the closest words in the list to an unknown word, the nearest first
+	Suggestions []string
+}
+
+// WordSubstitution replaces the word at Position, which is 1-based
+type WordSubstitution struct {
+	Position int
+	Word     string
+	// Distance is the edit distance from the replaced word
+	Distance int
+}
+
+// MnemonicDiagnosis is what the entry assistant tells about a mnemonic being entered
+type MnemonicDiagnosis struct {
+	Words []WordCheck
+	// Completions are the words in the list starting with the last word, when it is still being typed
+	Completions []string
+	// ValidWordCount means the number of words is in MnemonicWordCounts
+	ValidWordCount bool
+	// Valid means all the words are known and the checksum matches
+	Valid       bool
+	BadChecksum bool
+	// ChecksumFixes lists the single-word substitutions which make the checksum valid, the likely typos first.
+	// They are given for all the positions when the checksum does not match, and only for the unknown word
+	// when it is the only one.
+	ChecksumFixes []WordSubstitution
+}
+
+// Complete returns at most max words in the list starting with prefix, in the order of the list
+func (wl *Wordlist) Complete(prefix string, max int) []string {
+	prefix = normalizeWord(prefix)
+	var res []string
+	if prefix == "" {
+		return res
+	}
+	for _, w := range wl.Words {
+		if strings.HasPrefix(normalizeWord(w), prefix) {
+			res = append(res, w)
+			if len(res) == max {
+				break
+			}
+		}
+	}
+	return res
+}
+
+// Suggest returns at most max words in the list within MaxSuggestionDistance of word, the nearest first
+func (wl *Wordlist) Suggest(word string, max int) []string {
+	word = normalizeWord(word)
+	type candidate struct {
+		word     string
+		distance int
+	}
+	var candidates []candidate
+	for _, w := range wl.Words {
+		if d := editDistance(word, normalizeWord(w)); d <= MaxSuggestionDistance {
+			candidates = append(candidates, candidate{w, d})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+	var res []string
+	for i := 0; i < len(candidates) && i < max; i++ {
+		res = append(res, candidates[i].word)
+	}
+	return res
+}
+
+// Diagnose checks a mnemonic being entered. If input does not end with a space, its last word is taken
+// as being typed, and it is completed instead of being flagged when some words start with it.
+func (wl *Wordlist) Diagnose(input string) MnemonicDiagnosis {
+	var d MnemonicDiagnosis
+	words := strings.Fields(normalizeWord(input))
+	last, _ := utf8.DecodeLastRuneInString(input)
+	typing := len(words) != 0 && !unicode.IsSpace(last)
+	indexes := make([]int, len(words))
+	unknown := -1
+	unknownCount := 0
+	for i, w := range words {
+		check := WordCheck{Position: i + 1, Word: w}
+		indexes[i], check.Known = wl.index[w]
+		if check.Known {
+			check.Word = wl.Words[indexes[i]]
+		}
+		if i == len(words)-1 && typing {
+			d.Completions = wl.Complete(w, MaxCompletions)
+			check.Partial = !check.Known && len(d.Completions) != 0
+		}
+		if !check.Known && !check.Partial {
+			check.Suggestions = wl.Suggest(w, MaxSuggestions)
+		}
+		if !check.Known {
+			unknown = i
+			unknownCount++
+		}
+		d.Words = append(d.Words, check)
+	}
+	_, err := MnemonicEntropyBits(len(words))
+	d.ValidWordCount = err == nil
+	if !d.ValidWordCount || unknownCount > 1 {
+		return d
+	}
+	if unknownCount == 0 {
+		if _, ok := entropyOfIndexes(indexes); ok {
+			d.Valid = true
+			return d
+		}
+		d.BadChecksum = true
+		for i := range words {
+			d.ChecksumFixes = append(d.ChecksumFixes, wl.checksumFixes(indexes, i, words[i])...)
+		}
+	} else {
+		d.ChecksumFixes = wl.checksumFixes(indexes, unknown, words[unknown])
+	}
+	sort.SliceStable(d.ChecksumFixes, func(i, j int) bool {
+		return d.ChecksumFixes[i].Distance < d.ChecksumFixes[j].Distance
+	})
+	return d
+}
+
+// checksumFixes returns the words which make the checksum valid at position i, other than the current one
+func (wl *Wordlist) checksumFixes(indexes []int, i int, current string) []WordSubstitution {
+	var res []WordSubstitution
+	trial := append([]int{}, indexes...)
+	for j, w := range wl.Words {
+		if j == indexes[i] && normalizeWord(w) == current {
+			continue
+		}
+		trial[i] = j
+		if _, ok := entropyOfIndexes(trial); ok {
+			res = append(res, WordSubstitution{Position: i + 1, Word: w, Distance: editDistance(current, normalizeWord(w))})
+		}
+	}
+	return res
+}
+
+// entropyOfIndexes packs the 11-bit word indexes and checks the checksum, the number of indexes must be valid
+func entropyOfIndexes(indexes []int) ([]byte, bool) {
+	totalBits := len(indexes) * 11
+	checksumBits := totalBits / 33
+	buf := make([]byte, (totalBits+7)/8)
+	for i, idx := range indexes {
+		for b := 0; b < 11; b++ {
+			if idx&(1<<uint(10-b)) != 0 {
+				pos := i*11 + b
+				buf[pos/8] |= 0x80 >> uint(pos%8)
+			}
+		}
+	}
+	entropy := buf[:(totalBits-checksumBits)/8]
+	sum := sha256.Sum256(entropy)
+	// the checksum starts right after the entropy, at a byte boundary
+	checksum := buf[len(entropy)] >> uint(8-checksumBits)
+	return entropy, sum[0]>>uint(8-checksumBits) == checksum
+}
+
+// editDistance is the optimal string alignment distance, counting a swap of two adjacent letters as one edit
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(s)][len(t)]
+}
+
+func minInt(first int, others ...int) int {
+	for _, n := range others {
+		if n < first {
+			first = n
+		}
+	}
+	return first
+}
+
+// DiagnoseMnemonic runs the entry assistant with the wordlist of a language
+func DiagnoseMnemonic(language, input string) (MnemonicDiagnosis, error) {
+	wl, err := GetWordlist(language)
+	if err != nil {
+		return MnemonicDiagnosis{}, err
+	}
+	return wl.Diagnose(input), nil
+}
@@ -0,0 +1,127 @@
+package keykeeper
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the 12-word mnemonic of all zero entropy, whose last word carries the checksum
+var zeroMnemonic = strings.Repeat("abandon ", 11) + "about"
+
+func englishWordlist(t *testing.T) *Wordlist {
+	wl, err := GetWordlist(LanguageEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wl
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"ab", "ba", 1},
+		{"abcd", "acbd", 1},
+		{"about", "abuot", 1},
+		{"kitten", "sitting", 3},
+		// a transposed pair is not edited again
+		{"ca", "abc", 3},
+		{"né", "ne", 1},
+	} {
+		if d := editDistance(c.a, c.b); d != c.distance {
+			t.Fatalf("%q %q: %d instead of %d", c.a, c.b, d, c.distance)
+		}
+	}
+}
+
+func TestCompleteAndSuggest(t *testing.T) {
+	wl := englishWordlist(t)
+	if res := wl.Complete("zo", MaxCompletions); !reflect.DeepEqual(res, []string{"zone", "zoo"}) {
+		t.Fatal(res)
+	}
+	if res := wl.Complete("", MaxCompletions); len(res) != 0 {
+		t.Fatal(res)
+	}
+	if res := wl.Complete("ab", 3); len(res) != 3 {
+		t.Fatal(res)
+	}
+	if res := wl.Suggest("abandn", MaxSuggestions); len(res) == 0 || res[0] != "abandon" {
+		t.Fatal(res)
+	}
+	if res := wl.Suggest("xqxqxq", MaxSuggestions); len(res) != 0 {
+		t.Fatal(res)
+	}
+}
+
+func TestDiagnose(t *testing.T) {
+	wl := englishWordlist(t)
+	d := wl.Diagnose(zeroMnemonic + " ")
+	if !d.Valid || d.BadChecksum || len(d.Words) != 12 || len(d.Completions) != 0 {
+		t.Fatalf("%+v", d)
+	}
+
+	// a single-letter typo
+	d = wl.Diagnose("ABANDN " + strings.Repeat("abandon ", 10) + "about ")
+	w := d.Words[0]
+	if d.Valid || w.Known || w.Partial || len(w.Suggestions) == 0 || w.Suggestions[0] != "abandon" {
+		t.Fatalf("%+v", d)
+	}
+	// the only unknown word gets the fixes of the checksum
+	if len(d.ChecksumFixes) == 0 || d.ChecksumFixes[0] != (WordSubstitution{Position: 1, Word: "abandon", Distance: 1}) {
+		t.Fatalf("%+v", d.ChecksumFixes)
+	}
+
+	// the last word is being typed
+	d = wl.Diagnose("abandon aban")
+	w = d.Words[1]
+	if w.Known || !w.Partial || len(w.Suggestions) != 0 || !reflect.DeepEqual(d.Completions, []string{"abandon"}) {
+		t.Fatalf("%+v", d)
+	}
+	// but not after a space
+	d = wl.Diagnose("abandon aban ")
+	if w = d.Words[1]; w.Known || w.Partial || len(d.Completions) != 0 {
+		t.Fatalf("%+v", d)
+	}
+
+	// a word in the list, but not the right one
+	d = wl.Diagnose(strings.Repeat("abandon ", 11) + "above")
+	if d.Valid || !d.BadChecksum || !d.ValidWordCount {
+		t.Fatalf("%+v", d)
+	}
+	found := false
+	for i, fix := range d.ChecksumFixes {
+		if i > 0 && fix.Distance < d.ChecksumFixes[i-1].Distance {
+			t.Fatal("the fixes are not sorted", d.ChecksumFixes)
+		}
+		found = found || fix == WordSubstitution{Position: 12, Word: "about", Distance: 2}
+		if fix.Position == 12 && fix.Word == "above" {
+			t.Fatal("the current word is a fix")
+		}
+	}
+	if !found {
+		t.Fatal(d.ChecksumFixes)
+	}
+
+	// a bad number of words is told before the checksum, and two unknown words get no fixes
+	if d = wl.Diagnose(strings.Repeat("abandon ", 11)); d.ValidWordCount || d.BadChecksum || len(d.ChecksumFixes) != 0 {
+		t.Fatalf("%+v", d)
+	}
+	if d = wl.Diagnose("xx yy " + strings.Repeat("abandon ", 10)); !d.ValidWordCount || len(d.ChecksumFixes) != 0 {
+		t.Fatalf("%+v", d)
+	}
+}
+
+func TestDiagnoseMnemonic(t *testing.T) {
+	if d, err := DiagnoseMnemonic("", testMnemonic); err != nil || !d.Valid {
+		t.Fatal(err, d)
+	}
+	if _, err := DiagnoseMnemonic("klingon", testMnemonic); err == nil {
+		t.Fatal("an unknown language")
+	}
+}
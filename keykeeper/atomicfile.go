@@ -0,0 +1,38 @@
+package keykeeper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to a temporary file in the same directory as fname, syncs it,
+// and then renames it over fname. So fname always holds either the old or the new content,
+// even if the power is lost or the disk is full in the middle.
+func writeFileAtomic(fname string, data []byte) (err error) {
+	dir, base := filepath.Split(fname)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := ioutil.TempFile(dir, base+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmpName)
+		}
+	}()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return replaceFile(tmpName, fname)
+}
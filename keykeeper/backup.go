@@ -0,0 +1,147 @@
+package keykeeper
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBackupGenerations is the number of backups kept next to a keybase file.
+// Each Save keeps the previous content as a backup named "<keybase>.<sequence>-<timestamp>.bak". The sequence
+// number of a new backup is above those of the existing ones, and it orders the backups, because the clock
+// of an offline machine may go backwards. The timestamp is only for the users.
+const DefaultBackupGenerations = 5
+
+const (
+	backupSuffix     = ".bak"
+	backupTimeLayout = "20060102-150405.000"
+)
+
+type BackupInfo struct {
+	Path string
+	Time time.Time
+	Size int64
+	// seq orders the backups, the newest has the largest one
+	seq int
+}
+
+// now is replaced by the tests of a clock going backwards
+var now = time.Now
+
+func backupPath(fname string, seq int, t time.Time) string {
+	return fname + "." + strconv.Itoa(seq) + "-" + t.UTC().Format(backupTimeLayout) + backupSuffix
+}
+
+// newBackupPath returns the name of a new backup, whose sequence number follows the newest backup
+func newBackupPath(fname string, t time.Time) (string, error) {
+	backups, err := ListBackups(fname)
+	if err != nil {
+		return "", err
+	}
+	seq := 1
+	if len(backups) != 0 {
+		seq = backups[0].seq + 1
+	}
+	return backupPath(fname, seq, t), nil
+}
+
+// parseBackupStamp parses the sequence number and the timestamp in the name of a backup
+func parseBackupStamp(stamp string) (int, time.Time, error) {
+	i := strings.Index(stamp, "-")
+	if i < 0 {
+		return 0, time.Time{}, fmt.Errorf("missing backup sequence in %q", stamp)
+	}
+	seq, err := strconv.Atoi(stamp[:i])
+	if err != nil || seq <= 0 {
+		return 0, time.Time{}, fmt.Errorf("invalid backup sequence %q", stamp[:i])
+	}
+	t, err := time.Parse(backupTimeLayout, stamp[i+1:])
+	return seq, t, err
+}
+
+// ListBackups returns the backups of the keybase file fname, the newest comes first
+func ListBackups(fname string) ([]BackupInfo, error) {
+	dir, base := filepath.Split(fname)
+	if dir == "" {
+		dir = "."
+	}
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var res []BackupInfo
+	for _, fi := range fileInfos {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasPrefix(name, base+".") || !strings.HasSuffix(name, backupSuffix) {
+			continue
+		}
+		stamp := name[len(base)+1 : len(name)-len(backupSuffix)]
+		seq, t, err := parseBackupStamp(stamp)
+		if err != nil {
+			continue
+		}
+		res = append(res, BackupInfo{
+			Path: filepath.Join(dir, name),
+			Time: t,
+			Size: fi.Size(),
+			seq:  seq,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].seq > res[j].seq })
+	return res, nil
+}
+
+// backupAndWrite keeps the current content of fname as a new backup, writes data to fname atomically,
+// and then removes the oldest backups so that at most 'generations' of them are left.
+// Backups are neither made nor removed when generations is not positive.
+func backupAndWrite(fname string, data []byte, generations int) error {
+	if generations <= 0 {
+		return writeFileAtomic(fname, data)
+	}
+	old, err := ioutil.ReadFile(fname)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(old) != 0 {
+		path, err := newBackupPath(fname, now())
+		if err != nil {
+			return err
+		}
+		err = writeFileAtomic(path, old)
+		if err != nil {
+			return err
+		}
+	}
+	err = writeFileAtomic(fname, data)
+	if err != nil {
+		return err
+	}
+	backups, err := ListBackups(fname)
+	if err != nil {
+		return err
+	}
+	for i := generations; i < len(backups); i++ {
+		os.Remove(backups[i].Path)
+	}
+	return nil
+}
+
+// removeBackups deletes all the backups of the keybase file fname
+func removeBackups(fname string) error {
+	backups, err := ListBackups(fname)
+	if err != nil {
+		return err
+	}
+	for _, b := range backups {
+		err = os.Remove(b.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package keykeeper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBackupAndWrite(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "backup")
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "kb.json")
+	// the saves are quicker than the millisecond of the backup names
+	for i := 0; i <= DefaultBackupGenerations+2; i++ {
+		if err := backupAndWrite(fname, []byte(strconv.Itoa(i)), DefaultBackupGenerations); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := ListBackups(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != DefaultBackupGenerations {
+		t.Fatalf("%d backups are kept", len(backups))
+	}
+	// the newest first, each with the content before the next save
+	for i, b := range backups {
+		content, _ := ioutil.ReadFile(b.Path)
+		if want := strconv.Itoa(DefaultBackupGenerations + 1 - i); string(content) != want {
+			t.Fatalf("backup %d has %q instead of %q", i, content, want)
+		}
+	}
+}
+
+// the clock of an offline machine may go backwards, which must not prune the newest backups
+func TestBackupClockBackwards(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "backup")
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "kb.json")
+	defer func() { now = time.Now }()
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i := 0; i <= DefaultBackupGenerations+2; i++ {
+		// a day back on each save
+		now = func() time.Time { return start.AddDate(0, 0, -i) }
+		if err := backupAndWrite(fname, []byte(strconv.Itoa(i)), DefaultBackupGenerations); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := ListBackups(fname)
+	if err != nil || len(backups) != DefaultBackupGenerations {
+		t.Fatal(err, len(backups))
+	}
+	for i, b := range backups {
+		content, _ := ioutil.ReadFile(b.Path)
+		if want := strconv.Itoa(DefaultBackupGenerations + 1 - i); string(content) != want {
+			t.Fatalf("backup %d has %q instead of %q", i, content, want)
+		}
+		if want := start.AddDate(0, 0, -(DefaultBackupGenerations + 2 - i)); !b.Time.Equal(want) {
+			t.Fatal(b.Path, want)
+		}
+	}
+}
+
+func TestParseBackupStamp(t *testing.T) {
+	tm := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	for _, seq := range []int{1, 2, 10} {
+		path := backupPath("kb.json", seq, tm)
+		stamp := path[len("kb.json.") : len(path)-len(backupSuffix)]
+		n, parsed, err := parseBackupStamp(stamp)
+		if err != nil || !parsed.Equal(tm) || n != seq {
+			t.Fatal(path, parsed, n, err)
+		}
+	}
+	for _, stamp := range []string{"20200102-030405.006", "0-20200102-030405.006", "x-20200102-030405.006", "1-", "1"} {
+		if _, _, err := parseBackupStamp(stamp); err == nil {
+			t.Fatal(stamp)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package keykeeper
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// The results are published in docs/benchmark.md, please update it after changing the keybase or the storages.
+//
+//	go test -run '^$' -bench Keybase -benchtime 20x ./keykeeper -args -sizes 1000,10000,100000
+var benchSizes = flag.String("sizes", "1000,10000", "numbers of accounts in the keybase benchmarks, separated by commas")
+
+const benchPassphrase = "passphrase"
+
+// the real KDF would make creating 100k accounts take hours, and it costs the same at any size
+var cheapKdf = KdfParams{Algo: KdfScrypt, N: 2, R: 1, P: 1}
+
+func randomBytes(b *testing.B, n int) []byte {
+	bz := make([]byte, n)
+	if _, err := rand.Read(bz); err != nil {
+		b.Fatal(err)
+	}
+	return bz
+}
+
+// fakeAccount has the same size as a real account, without deriving the address from a mnemonic
+func fakeAccount(b *testing.B, i int) AccountInfo {
+	return AccountInfo{
+		Memo:              "customer " + strconv.Itoa(i),
+		Address:           sdk.AccAddress(randomBytes(b, 20)).String(),
+		Kdf:               &KdfParams{Algo: cheapKdf.Algo, N: cheapKdf.N, R: cheapKdf.R, P: cheapKdf.P, Salt: randomBytes(b, KdfSaltLength)},
+		PassphraseCksum:   randomBytes(b, 32),
+		EncryptedMnemonic: randomBytes(b, len(testMnemonic)+16),
+	}
+}
+
+type benchKeybase struct {
+	location string
+	size     int
+	accounts []AccountInfo
+}
+
+// create makes a keybase with a keybase passphrase derived by the default KDF, so that the integrity
+// manifest is included in every save
+func (s *benchKeybase) create(b *testing.B) {
+	DefaultKdfParams, realKdfParams = realKdfParams, DefaultKdfParams
+	defer func() { DefaultKdfParams, realKdfParams = realKdfParams, DefaultKdfParams }()
+	kb := &MyKeyBase{}
+	if err := kb.Open(s.location, OpenOptions{}); err != nil {
+		b.Fatal(err)
+	}
+	defer kb.Close()
+	if err := kb.SetKeybasePassphrase("", benchPassphrase); err != nil {
+		b.Fatal(err)
+	}
+	s.accounts = make([]AccountInfo, s.size)
+	for i := range s.accounts {
+		s.accounts[i] = fakeAccount(b, i)
+		if err := kb.AddAccount(s.accounts[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := kb.Save(); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// open uses the default settings, so the file storage keeps DefaultBackupGenerations backups
+func (s *benchKeybase) open(b *testing.B) *MyKeyBase {
+	kb := &MyKeyBase{}
+	err := kb.Open(s.location, OpenOptions{Prompt: func() (string, error) { return benchPassphrase, nil }})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return kb
+}
+
+func (s *benchKeybase) benchOpen(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		s.open(b).Close()
+	}
+}
+
+func (s *benchKeybase) benchLookup(b *testing.B) {
+	kb := s.open(b)
+	defer kb.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := kb.GetAccountInfo(s.accounts[i%len(s.accounts)].Address); !ok {
+			b.Fatal("account not found")
+		}
+	}
+}
+
+func (s *benchKeybase) benchCreate(b *testing.B) {
+	kb := s.open(b)
+	defer kb.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := kb.AddAccount(fakeAccount(b, s.size+i)); err != nil {
+			b.Fatal(err)
+		}
+		if err := kb.Save(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchDelete deletes real accounts, because DeleteAccount checks the passphrase
+func (s *benchKeybase) benchDelete(b *testing.B) {
+	kb := s.open(b)
+	defer kb.Close()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		acc, err := NewAccountInfoWithKdf("to delete", testMnemonic, benchPassphrase, cheapKdf)
+		if err == nil {
+			err = kb.AddAccount(acc)
+		}
+		if err == nil {
+			err = kb.Save()
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if err := kb.DeleteAccount(acc.Address, benchPassphrase); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkKeybase(b *testing.B, storage string) {
+	dir, _ := ioutil.TempDir("", "kbbench")
+	defer os.RemoveAll(dir)
+	for _, sizeStr := range strings.Split(*benchSizes, ",") {
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		s := &benchKeybase{size: size}
+		name := fmt.Sprintf("%s-%d", storage, size)
+		switch storage {
+		case "mem":
+			// the memory keybases live as long as the process, so each run needs new names for -count
+			s.location = "mem://" + filepath.Base(dir) + "-" + name
+		case "file":
+			s.location = "file://" + filepath.Join(dir, name+".json")
+		case "dir":
+			s.location = "dir://" + filepath.Join(dir, name)
+		}
+		s.create(b)
+		b.Run(sizeStr+"/open", s.benchOpen)
+		b.Run(sizeStr+"/lookup", s.benchLookup)
+		b.Run(sizeStr+"/create", s.benchCreate)
+		b.Run(sizeStr+"/delete", s.benchDelete)
+	}
+}
+
+func BenchmarkKeybaseMem(b *testing.B) {
+	benchmarkKeybase(b, "mem")
+}
+
+func BenchmarkKeybaseFile(b *testing.B) {
+	benchmarkKeybase(b, "file")
+}
+
+func BenchmarkKeybaseDir(b *testing.B) {
+	benchmarkKeybase(b, "dir")
+}
@@ -0,0 +1,133 @@
+package keykeeper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// The kinds of entropy supplied by the user
+const (
+	EntropyDice  = "dice"
+	EntropyCoins = "coins"
+	EntropyHex   = "hex"
+)
+
+// EntropySystemLength is the size of the system randomness mixed with the user entropy
+const EntropySystemLength = 32
+
+// entropyMixLabel tells the mixing construction apart from other uses of HMAC-SHA256, and versions it
+const entropyMixLabel = "ColdWallet.win user entropy v1"
+
+// UserEntropy is the entropy supplied by the user, e.g. dice rolls written down on paper.
+// Input is normalized by ParseUserEntropy: the digits 1 to 6 for dice, 0 (tails) and 1 (heads) for coins,
+// and lower-case hex digits for hex.
+type UserEntropy struct {
+	Kind  string
+	Input string
+}
+
+// ParseUserEntropy normalizes the input of the given kind. Spaces, commas and dashes between the symbols are ignored,
+// and coin flips can also be written as "h" and "t".
+func ParseUserEntropy(kind, input string) (UserEntropy, error) {
+	var sb strings.Builder
+	for i, c := range strings.ToLower(input) {
+		if strings.ContainsRune(" \t\r\n,-", c) {
+			continue
+		}
+		var ok bool
+		switch kind {
+		case EntropyDice:
+			ok = c >= '1' && c <= '6'
+		case EntropyCoins:
+			switch c {
+			case 'h':
+				c = '1'
+			case 't':
+				c = '0'
+			}
+			ok = c == '0' || c == '1'
+		case EntropyHex:
+			ok = c >= '0' && c <= '9' || c >= 'a' && c <= 'f'
+		default:
+			return UserEntropy{}, fmt.Errorf("%w: unknown kind %s", ErrInvalidEntropy, kind)
+		}
+		if !ok {
+			return UserEntropy{}, fmt.Errorf("%w: invalid character %q at offset %d for %s", ErrInvalidEntropy, c, i, kind)
+		}
+		sb.WriteRune(c)
+	}
+	if sb.Len() == 0 {
+		return UserEntropy{}, fmt.Errorf("%w: empty input", ErrInvalidEntropy)
+	}
+	return UserEntropy{Kind: kind, Input: sb.String()}, nil
+}
+
+// Bits estimates the entropy of the input, assuming fair dice and coins and random hex digits
+func (e UserEntropy) Bits() float64 {
+	perSymbol := 0.0
+	switch e.Kind {
+	case EntropyDice:
+		perSymbol = math.Log2(6)
+	case EntropyCoins:
+		perSymbol = 1
+	case EntropyHex:
+		perSymbol = 4
+	}
+	return perSymbol * float64(len(e.Input))
+}
+
+// EntropyMix records everything needed to reproduce the entropy of a mnemonic from the user entropy.
+// Entropy is the first bytes of HMAC-SHA256 keyed with System over the message
+//     "ColdWallet.win user entropy v1" || 0x00 || Kind || 0x00 || Input
+// truncated to the entropy size of the mnemonic. So anyone given User and the published System can reproduce it,
+// and the mnemonic is unpredictable as long as one of the two contributions is kept secret.
+type EntropyMix struct {
+	User    UserEntropy
+	System  []byte
+	Entropy []byte
+}
+
+// MixEntropy mixes the user entropy with a given system contribution, which is how a mix is audited
+func MixEntropy(user UserEntropy, system []byte, words int) (EntropyMix, error) {
+	bits, err := MnemonicEntropyBits(words)
+	if err != nil {
+		return EntropyMix{}, err
+	}
+	if len(system) != EntropySystemLength {
+		return EntropyMix{}, fmt.Errorf("%w: the system contribution must have %d bytes", ErrInvalidEntropy, EntropySystemLength)
+	}
+	mac := hmac.New(sha256.New, system)
+	mac.Write([]byte(entropyMixLabel))
+	mac.Write([]byte{0})
+	mac.Write([]byte(user.Kind))
+	mac.Write([]byte{0})
+	mac.Write([]byte(user.Input))
+	return EntropyMix{User: user, System: system, Entropy: mac.Sum(nil)[:bits/8]}, nil
+}
+
+// NewEntropyMix mixes the user entropy with fresh system randomness
+func NewEntropyMix(user UserEntropy, words int) (EntropyMix, error) {
+	system := make([]byte, EntropySystemLength)
+	if _, err := io.ReadFull(SystemRandom, system); err != nil {
+		return EntropyMix{}, err
+	}
+	return MixEntropy(user, system, words)
+}
+
+// Mnemonic returns the mnemonic of the mixed entropy in a language, which is also the candidate 0 of GenerateMnemonic
+func (m EntropyMix) Mnemonic(language string) (string, error) {
+	return m.CandidateMnemonic(language, 0)
+}
+
+// CandidateMnemonic returns the mnemonic found by GenerateMnemonic at index when it starts from the mixed entropy
+func (m EntropyMix) CandidateMnemonic(language string, index uint64) (string, error) {
+	wl, err := GetWordlist(language)
+	if err != nil {
+		return "", err
+	}
+	return wl.NewMnemonic(CandidateEntropy(m.Entropy, index))
+}
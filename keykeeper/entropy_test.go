@@ -0,0 +1,49 @@
+package keykeeper
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestParseUserEntropy(t *testing.T) {
+	for _, c := range []struct {
+		kind, input, expected string
+	}{
+		{EntropyDice, "1 2 3-4,5 6 66", "12345666"},
+		{EntropyCoins, "HTht01", "101001"},
+		{EntropyHex, "DE ad-BE,ef", "deadbeef"},
+	} {
+		u, err := ParseUserEntropy(c.kind, c.input)
+		if err != nil || u.Input != c.expected {
+			t.Fatal(c, u, err)
+		}
+	}
+	for _, c := range [][2]string{{EntropyDice, "1237"}, {EntropyCoins, "hx"}, {EntropyHex, "0g"}, {EntropyHex, " "}, {"cards", "1"}} {
+		if _, err := ParseUserEntropy(c[0], c[1]); !errors.Is(err, ErrInvalidEntropy) {
+			t.Fatal(c, err)
+		}
+	}
+}
+
+// TestMixEntropy checks the construction documented in docs/entropy.md, with the answers of its Python example
+func TestMixEntropy(t *testing.T) {
+	u, _ := ParseUserEntropy(EntropyDice, "12345666")
+	system := make([]byte, EntropySystemLength)
+	for i := range system {
+		system[i] = byte(i)
+	}
+	m, err := MixEntropy(u, system, 12)
+	if err != nil || hex.EncodeToString(m.Entropy) != "0514750f23cf4b7be987e6c9d052a7d7" {
+		t.Fatal(hex.EncodeToString(m.Entropy), err)
+	}
+	if c := hex.EncodeToString(CandidateEntropy(m.Entropy, 5)); c != "12cfa893ed3c17cccb0735cb300c726e" {
+		t.Fatal(c)
+	}
+	if _, err := MixEntropy(u, system[:16], 12); !errors.Is(err, ErrInvalidEntropy) {
+		t.Fatal(err)
+	}
+	if _, err := MixEntropy(u, system, 13); !errors.Is(err, ErrInvalidWordCount) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,102 @@
+package keykeeper
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoSuchAccount is returned when an address is not in the keybase
+	ErrNoSuchAccount = errors.New("No such account")
+	// ErrBadPassphrase is returned when a passphrase does not match the stored checksum
+	ErrBadPassphrase = errors.New("The passphrase is incorrect")
+	// ErrDecryptFailed is returned by AesGcmDecrypt when the key is wrong or the ciphertext was modified
+	ErrDecryptFailed = errors.New("Failed to decrypt: wrong key or corrupted data")
+	// ErrInvalidMnemonic is returned when a mnemonic has wrong words, a wrong length or a wrong checksum
+	ErrInvalidMnemonic = errors.New("Invalid mnemonic")
+	// ErrCorruptedAccount is returned when an account in the keybase can not be decoded
+	ErrCorruptedAccount = errors.New("The account's data in the keybase is corrupted")
+	// ErrInvalidHDPath is returned by ParseHDPath
+	ErrInvalidHDPath = errors.New("Invalid HD path")
+	// ErrUnsupportedKdf is returned when an account uses a KDF that this program does not know
+	ErrUnsupportedKdf = errors.New("Unsupported KDF")
+
+	ErrInvalidKeybase          = errors.New("Invalid keybase")
+	ErrUnsupportedFormat       = errors.New("Unsupported keybase format version")
+	ErrNotPlainFile            = errors.New("Not a plain file")
+	ErrBadKeybasePassphrase    = errors.New("The keybase passphrase is incorrect")
+	ErrKeybasePassphraseNeeded = errors.New("The keybase is sealed and needs a passphrase to open")
+	ErrAlreadySealed           = errors.New("The keybase is already sealed")
+	ErrNotSealed               = errors.New("The keybase is not sealed")
+	ErrUnsealFirst             = errors.New("The keybase is sealed, unseal it before removing its passphrase")
+	ErrEmptyPassphrase         = errors.New("The keybase can not be sealed under an empty passphrase")
+	// ErrTampered is wrapped by *TamperError, which tells what has been changed
+	ErrTampered = errors.New("The keybase has been modified since it was last saved")
+	// ErrKeybaseLocked means the keybase is open in another program or another copy of this program
+	ErrKeybaseLocked = errors.New("The keybase is being used by another program")
+	ErrReadOnly      = errors.New("The keybase is opened read-only")
+	ErrNotOpen       = errors.New("The keybase is not opened")
+	// ErrUnsupportedScheme is returned by NewStorage for an unknown URI scheme
+	ErrUnsupportedScheme = errors.New("Unsupported keybase location scheme")
+	// ErrAccountExists is returned when an imported account is already in the keybase
+	ErrAccountExists = errors.New("The account already exists")
+	// ErrInvalidPrivKey is returned when an imported private key can not be decoded
+	ErrInvalidPrivKey = errors.New("Invalid private key")
+	// ErrUnsupportedKeyType is returned when an account holds a type of key that this program does not know
+	ErrUnsupportedKeyType = errors.New("Unsupported key type")
+	// ErrNoMnemonic is returned when the mnemonic of an account holding a raw private key is asked for
+	ErrNoMnemonic = errors.New("The account has no mnemonic, it holds a raw private key")
+	// ErrInvalidKeystore is returned when a JSON of Web3 Secret Storage can not be decoded
+	ErrInvalidKeystore = errors.New("Invalid keystore")
+	// ErrNotKeyring is returned by OpenKeyring when a directory has no keyring of cetcli
+	ErrNotKeyring = errors.New("No keyring of cetcli is found in the directory")
+	// ErrNotLocalKey is returned when a key in a keyring is on a ledger or only has its public key
+	ErrNotLocalKey = errors.New("The private key is not held in the keyring")
+	// ErrNoSuchWallet is returned when a wallet ID is not in the keybase, wrapped with the ID in an AccountError
+	ErrNoSuchWallet = errors.New("No such wallet")
+	// ErrWalletExists is returned when a wallet with the same mnemonic, coin type and account is already in the keybase
+	ErrWalletExists = errors.New("The wallet already exists")
+	// ErrInvalidWordCount is returned when a mnemonic of an unsupported length is asked for
+	ErrInvalidWordCount = errors.New("The number of mnemonic words must be 12, 15, 18, 21 or 24")
+	// ErrInvalidEntropy is returned when the entropy supplied by the user can not be parsed
+	ErrInvalidEntropy = errors.New("Invalid user entropy")
+	// ErrEntropyHealth is returned when the system random number generator fails its health tests
+	ErrEntropyHealth = errors.New("The system random number generator looks broken")
+	// ErrUnsupportedLanguage is returned when the wordlist of a language is unknown or not loaded
+	ErrUnsupportedLanguage = errors.New("The wordlist of the language is not available")
+	// ErrInvalidWordlist is returned when a wordlist file does not have 2048 distinct words
+	ErrInvalidWordlist = errors.New("Invalid wordlist")
+)
+
+// AccountError records the address of the account that an error is about
+type AccountError struct {
+	Address string
+	Err     error
+}
+
+func (e *AccountError) Error() string {
+	return e.Err.Error() + ": " + e.Address
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+func accountError(addr string, err error) error {
+	return &AccountError{Address: addr, Err: err}
+}
+
+// FormatVersionError is returned when a keybase file is written by a newer program
+type FormatVersionError struct {
+	Version   int
+	Supported int
+}
+
+func (e *FormatVersionError) Error() string {
+	return fmt.Sprintf("The keybase's format version is %d, but this program only supports up to %d, please upgrade it",
+		e.Version, e.Supported)
+}
+
+func (e *FormatVersionError) Unwrap() error {
+	return ErrUnsupportedFormat
+}
@@ -0,0 +1,56 @@
+package keykeeper
+
+import (
+	"path/filepath"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/mintkey"
+)
+
+// ArmorFileExt is the extension of the files written by ExportArmoredPrivKeys
+const ArmorFileExt = ".armor"
+
+// ExportArmoredPrivKey returns the private key of an account in the ASCII armor of `cetcli keys export`,
+// encrypted with exportPassphrase. It can be read by `cetcli keys import` and by ImportArmoredPrivKey.
+func (kb *MyKeyBase) ExportArmoredPrivKey(addr, passphrase, exportPassphrase string) (string, error) {
+	privk, err := kb.privKeyOf(addr, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return mintkey.EncryptArmorPrivKey(privk, exportPassphrase), nil
+}
+
+// ExportArmoredPrivKeys writes the armored private keys of the accounts into dir, one file "<address>.armor"
+// for each, and returns the names of the files. passphraseOf is called to get the passphrase of each account.
+// The files are only written after all the keys are exported, so a wrong passphrase leaves no partial output.
+func (kb *MyKeyBase) ExportArmoredPrivKeys(dir string, addrs []string, passphraseOf func(addr string) (string, error),
+	exportPassphrase string) ([]string, error) {
+	armors := make([]string, len(addrs))
+	for i, addr := range addrs {
+		passphrase, err := passphraseOf(addr)
+		if err != nil {
+			return nil, err
+		}
+		armors[i], err = kb.ExportArmoredPrivKey(addr, passphrase, exportPassphrase)
+		if err != nil {
+			return nil, err
+		}
+	}
+	fnames := make([]string, len(addrs))
+	for i, addr := range addrs {
+		fnames[i] = filepath.Join(dir, addr+ArmorFileExt)
+		err := writeFileAtomic(fnames[i], []byte(armors[i]))
+		if err != nil {
+			return fnames[:i], err
+		}
+	}
+	return fnames, nil
+}
+
+func ExportArmoredPrivKey(addr, passphrase, exportPassphrase string) (string, error) {
+	return KB.ExportArmoredPrivKey(addr, passphrase, exportPassphrase)
+}
+
+func ExportArmoredPrivKeys(dir string, addrs []string, passphraseOf func(addr string) (string, error),
+	exportPassphrase string) ([]string, error) {
+	return KB.ExportArmoredPrivKeys(dir, addrs, passphraseOf, exportPassphrase)
+}
@@ -0,0 +1,79 @@
+package keykeeper
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArmorRoundTrip(t *testing.T) {
+	src := openTestKeybase(t, "mem://")
+	defer src.Close()
+	if _, err := src.CreateAccount("memo", testMnemonic, "p"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.ExportArmoredPrivKey(testAddress, "bad", "exp"); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatal(err)
+	}
+	armor, err := src.ExportArmoredPrivKey(testAddress, "p", "exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := openTestKeybase(t, "mem://")
+	defer dst.Close()
+	if _, err := dst.ImportArmoredPrivKey("raw", armor, "bad", "q"); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatal(err)
+	}
+	acc, err := dst.ImportArmoredPrivKey("raw", armor, "exp", "q")
+	if err != nil || acc.Address != testAddress || acc.GetKeyType() != KeyTypeSecp256k1 {
+		t.Fatal(err, acc)
+	}
+	// the signatures are deterministic, so the same key gives the same signature
+	msg := []byte(`{"chain_id":"coinexdex"}`)
+	sig1, err1 := src.Sign(testAddress, "p", msg)
+	sig2, err2 := dst.Sign(testAddress, "q", msg)
+	if err1 != nil || err2 != nil || sig1 != sig2 {
+		t.Fatal(err1, err2, sig1, sig2)
+	}
+	hex1, _ := src.ExportPrivKeyHex(testAddress, "p")
+	hex2, _ := dst.ExportPrivKeyHex(testAddress, "q")
+	if hex1 == "" || hex1 != hex2 {
+		t.Fatal(hex1, hex2)
+	}
+}
+
+func TestExportArmoredPrivKeys(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "armor")
+	defer os.RemoveAll(dir)
+	kb := openTestKeybase(t, "mem://")
+	defer kb.Close()
+	acc0, _ := kb.CreateAccount("0", testMnemonic, "p0")
+	acc1, err := kb.CreateAccountWithPath("1", testMnemonic, "p1", HDPath{CoinType: DefaultCoinType, Index: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := []string{acc0.Address, acc1.Address}
+	passphrases := map[string]string{acc0.Address: "p0", acc1.Address: "bad"}
+	passphraseOf := func(addr string) (string, error) { return passphrases[addr], nil }
+	// a wrong passphrase leaves no partial output
+	if _, err := kb.ExportArmoredPrivKeys(dir, addrs, passphraseOf, "exp"); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatal(err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatal(len(files))
+	}
+	passphrases[acc1.Address] = "p1"
+	fnames, err := kb.ExportArmoredPrivKeys(dir, addrs, passphraseOf, "exp")
+	if err != nil || len(fnames) != 2 || fnames[1] != filepath.Join(dir, acc1.Address+ArmorFileExt) {
+		t.Fatal(err, fnames)
+	}
+	armor, _ := ioutil.ReadFile(fnames[1])
+	dst := openTestKeybase(t, "mem://")
+	defer dst.Close()
+	if acc, err := dst.ImportArmoredPrivKey("1", string(armor), "exp", "q"); err != nil || acc.Address != acc1.Address {
+		t.Fatal(err, acc)
+	}
+}
@@ -0,0 +1,172 @@
+package keykeeper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// KeybaseFormatVersion is the newest format this program can read and the one it writes.
+// Version 0 is the bare JSON array of AccountInfo written by the early releases.
+const KeybaseFormatVersion = 8
+
+// Creator is recorded in the header of newly created keybases
+var Creator = "ColdWallet.win"
+
+// KeybaseHeader is the metadata stored along with the accounts in a keybase file
+type KeybaseHeader struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	CreatedBy string    `json:"created_by"`
+	// Kdf holds the default KDF parameters for new accounts in this keybase. Its Salt is always empty.
+	Kdf KdfParams `json:"kdf"`
+	// Protected is set while the keybase has a keybase passphrase, so removing the passphrase and the integrity
+	// manifest from the file is reported as tampering. The programs which do not know it drop it when they save.
+	Protected bool `json:"protected,omitempty"`
+}
+
+// KeybaseContent is what a Storage loads and stores. It is also the layout of a single-JSON keybase file.
+type KeybaseContent struct {
+	KeybaseHeader
+	// Secret is present when the keybase has a keybase-level passphrase
+	Secret    *KeybaseSecret     `json:"secret,omitempty"`
+	Accounts  []AccountInfo      `json:"accounts,omitempty"`
+	Wallets   []WalletInfo       `json:"wallets,omitempty"`
+	Integrity *IntegrityManifest `json:"integrity,omitempty"`
+	// Sealed replaces Accounts, Wallets and Integrity when the whole keybase is encrypted
+	Sealed []byte `json:"sealed,omitempty"`
+}
+
+func NewKeybaseHeader() KeybaseHeader {
+	return KeybaseHeader{
+		Version:   KeybaseFormatVersion,
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: Creator,
+		Kdf:       DefaultKdfParams,
+	}
+}
+
+// migrations[i] converts a keybase from format version i to version i+1. Most versions only add optional
+// fields, which the older programs would ignore or misread. Their layout does not change, and the version is
+// bumped only so that the older programs refuse the keybase instead of losing or misusing the new fields.
+var migrations = []func(content []byte) ([]byte, error){
+	migrateV0ToV1,
+	// Version 2 adds the optional "sealed" field. Older programs would take a sealed keybase as an empty one.
+	sameLayout,
+	migrateV2ToV3,
+	// Version 4 adds the optional "hd_path" field to accounts. Older programs would ignore it and derive a wrong key.
+	sameLayout,
+	// Version 5 adds the optional "wallets" field and the child accounts that refer to them. Older programs
+	// would take a child account without its own mnemonic as a corrupted one.
+	sameLayout,
+	// Version 6 adds the accounts holding a raw private key in "encrypted_privkey", whose "key_type" is
+	// "secp256k1". Older programs would take them as corrupted accounts.
+	sameLayout,
+	// Version 7 adds the optional "encrypted_bip39_passphrase" field to accounts and wallets. Older programs
+	// would ignore it and derive a wrong key from the mnemonic alone.
+	sameLayout,
+	// Version 8 adds the optional "language" field to accounts and wallets. Older programs would take
+	// the mnemonics in other languages as invalid English ones.
+	sameLayout,
+}
+
+// sameLayout is the migration to a version which only adds optional fields
+func sameLayout(content []byte) ([]byte, error) {
+	return content, nil
+}
+
+// Version 0 is a bare array, which gets wrapped into an envelope with the header
+func migrateV0ToV1(content []byte) ([]byte, error) {
+	var accounts []AccountInfo
+	err := json.Unmarshal(content, &accounts)
+	if err != nil {
+		return nil, err
+	}
+	header := NewKeybaseHeader()
+	header.Version = 1
+	header.CreatedBy = "migrated from format version 0"
+	return json.Marshal(KeybaseContent{KeybaseHeader: header, Accounts: accounts})
+}
+
+// Version 3 moves the KDF parameters of the keybase passphrase out of "sealed" into "secret",
+// because the passphrase is also used for the integrity manifest of unsealed keybases
+func migrateV2ToV3(content []byte) ([]byte, error) {
+	var kf map[string]json.RawMessage
+	err := json.Unmarshal(content, &kf)
+	if err != nil {
+		return nil, err
+	}
+	sealedJSON, ok := kf["sealed"]
+	if !ok {
+		return content, nil
+	}
+	var sealed struct {
+		KeybaseSecret
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	err = json.Unmarshal(sealedJSON, &sealed)
+	if err != nil {
+		return nil, err
+	}
+	kf["secret"], err = json.Marshal(sealed.KeybaseSecret)
+	if err != nil {
+		return nil, err
+	}
+	kf["sealed"], err = json.Marshal(sealed.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(kf)
+}
+
+func detectFormatVersion(content []byte) (int, error) {
+	if bytes.HasPrefix(content, []byte("[")) {
+		return 0, nil
+	}
+	var header struct {
+		Version *int `json:"version"`
+	}
+	err := json.Unmarshal(content, &header)
+	if err != nil {
+		return 0, err
+	}
+	if header.Version == nil || *header.Version < 1 {
+		return 0, fmt.Errorf("%w: missing format version", ErrInvalidKeybase)
+	}
+	return *header.Version, nil
+}
+
+// migrateKeybase runs all the needed migrations on the JSON of a keybase
+func migrateKeybase(content []byte) ([]byte, error) {
+	content = bytes.TrimSpace(content)
+	version, err := detectFormatVersion(content)
+	if err != nil {
+		return nil, err
+	}
+	if version > KeybaseFormatVersion {
+		return nil, &FormatVersionError{Version: version, Supported: KeybaseFormatVersion}
+	}
+	for ; version < KeybaseFormatVersion; version++ {
+		content, err = migrations[version](content)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+// decodeKeybase parses the content of a keybase file, running all the needed migrations
+func decodeKeybase(content []byte) (kf KeybaseContent, err error) {
+	content, err = migrateKeybase(content)
+	if err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &kf)
+	return
+}
+
+func encodeKeybase(kf KeybaseContent) ([]byte, error) {
+	kf.Version = KeybaseFormatVersion
+	return json.Marshal(kf)
+}
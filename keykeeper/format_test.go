@@ -0,0 +1,88 @@
+package keykeeper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMigrateV0(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "format")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	// the bare array of the early releases
+	content, _ := json.Marshal([]AccountInfo{legacyAccount(t, "p")})
+	if err := ioutil.WriteFile(location, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	kf, err := decodeKeybase(content)
+	if err != nil || len(kf.Accounts) != 1 || kf.CreatedBy != "migrated from format version 0" {
+		t.Fatal(err, kf)
+	}
+
+	kb := openTestKeybase(t, location)
+	if mnemonic, err := kb.GetMnemonic(testAddress, "p"); err != nil || mnemonic != testMnemonic {
+		t.Fatal(err, mnemonic)
+	}
+	if err := kb.Save(); err != nil {
+		t.Fatal(err)
+	}
+	kb.Close()
+	content, _ = ioutil.ReadFile(location)
+	if version, err := detectFormatVersion(content); err != nil || version != KeybaseFormatVersion {
+		t.Fatal(err, version)
+	}
+}
+
+func TestMigrateV2(t *testing.T) {
+	kdf, err := NewKdfParams(DefaultKdfParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := json.Marshal(map[string]interface{}{
+		"version": 2,
+		"sealed": map[string]interface{}{
+			"kdf":              kdf,
+			"passphrase_cksum": []byte{1, 2},
+			"ciphertext":       []byte{3, 4},
+		},
+	})
+	kf, err := decodeKeybase(v2)
+	if err != nil || kf.Secret == nil || !bytes.Equal(kf.Secret.PassphraseCksum, []byte{1, 2}) ||
+		!bytes.Equal(kf.Secret.Kdf.Salt, kdf.Salt) || !bytes.Equal(kf.Sealed, []byte{3, 4}) {
+		t.Fatal(err, kf)
+	}
+}
+
+func TestNewerFormatVersion(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "format")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	content := []byte(`{"version":` + strconv.Itoa(KeybaseFormatVersion+1) + `,"accounts":[]}`)
+	if err := ioutil.WriteFile(location, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Open(location, OpenOptions{})
+	var verErr *FormatVersionError
+	if !errors.Is(err, ErrUnsupportedFormat) || !errors.As(err, &verErr) || verErr.Version != KeybaseFormatVersion+1 || verErr.Supported != KeybaseFormatVersion {
+		t.Fatal(err)
+	}
+	// the keybase is left as it is
+	if saved, _ := ioutil.ReadFile(location); !bytes.Equal(saved, content) {
+		t.Fatal(string(saved))
+	}
+
+	for _, bad := range []string{`{"accounts":[]}`, `{"version":0}`, `{"version":"1"}`} {
+		if _, err := decodeKeybase([]byte(bad)); err == nil {
+			t.Fatal(bad)
+		}
+	}
+	if _, err := decodeKeybase([]byte(`{"version":0}`)); !errors.Is(err, ErrInvalidKeybase) {
+		t.Fatal(err)
+	}
+}
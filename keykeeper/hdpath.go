@@ -0,0 +1,46 @@
+package keykeeper
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/hd"
+)
+
+// CosmosCoinType is used by the Cosmos Hub wallets, whose mnemonics can be restored with it
+const CosmosCoinType = 118
+
+// HDPath is the BIP44 path m/44'/coin_type'/account'/0/index, which derives an account's key from its mnemonic
+type HDPath struct {
+	CoinType uint32 `json:"coin_type"`
+	Account  uint32 `json:"account"`
+	Index    uint32 `json:"index"`
+}
+
+// DefaultHDPath is m/44'/688'/0'/0/0, which is also used for the accounts created before paths were recorded
+var DefaultHDPath = HDPath{CoinType: DefaultCoinType}
+
+// ParseHDPath parses a path like "m/44'/118'/0'/0/0", and the leading "m/" is optional
+func ParseHDPath(path string) (HDPath, error) {
+	params, err := hd.NewParamsFromPath(strings.TrimPrefix(strings.TrimSpace(path), "m/"))
+	if err != nil {
+		return HDPath{}, fmt.Errorf("%w: %v", ErrInvalidHDPath, err)
+	}
+	if params.Change {
+		return HDPath{}, fmt.Errorf("%w: the change field must be 0", ErrInvalidHDPath)
+	}
+	for _, n := range []uint32{params.CoinType, params.Account, params.AddressIndex} {
+		if n >= 1<<31 {
+			return HDPath{}, fmt.Errorf("%w: %d is too large", ErrInvalidHDPath, n)
+		}
+	}
+	return HDPath{CoinType: params.CoinType, Account: params.Account, Index: params.AddressIndex}, nil
+}
+
+func (p HDPath) String() string {
+	return "m/" + p.params().String()
+}
+
+func (p HDPath) params() *hd.BIP44Params {
+	return hd.NewFundraiserParams(p.Account, p.CoinType, p.Index)
+}
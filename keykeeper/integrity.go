@@ -0,0 +1,202 @@
+package keykeeper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+)
+
+// IntegrityEntry holds the MAC of one account record in the keybase
+type IntegrityEntry struct {
+	Address string `json:"address"`
+	Mac     []byte `json:"mac"`
+}
+
+// IntegrityManifest is written by Save when the keybase has a keybase passphrase. It records the MAC of
+// every account and every wallet in order, and its own MAC covers the entries and the keybase's default KDF parameters.
+// Removing the passphrase along with the manifest is detected by the Protected flag of the header, but
+// not when the flag is removed as well, so the GUI warns each time it opens a keybase without a keybase passphrase.
+type IntegrityManifest struct {
+	Entries []IntegrityEntry `json:"entries"`
+	// Wallets holds the MACs of the wallets, where Address is the ID of a wallet
+	Wallets []IntegrityEntry `json:"wallets,omitempty"`
+	Mac     []byte           `json:"mac"`
+}
+
+// TamperError lists the differences between the accounts in a keybase and those at its last legitimate Save
+type TamperError struct {
+	// ManifestInvalid means the manifest itself is missing or modified, so nothing else can be told
+	ManifestInvalid bool
+	// ProtectionRemoved means the keybase passphrase and the manifest have been removed from a protected keybase
+	ProtectionRemoved bool
+	Modified          []string
+	Added             []string
+	Removed           []string
+	Reordered         bool
+}
+
+func (e *TamperError) Error() string {
+	if e.ManifestInvalid {
+		return ErrTampered.Error() + ": the integrity manifest is missing or modified"
+	}
+	if e.ProtectionRemoved {
+		return ErrTampered.Error() + ": the keybase passphrase and the integrity manifest have been removed"
+	}
+	var parts []string
+	if len(e.Modified) != 0 {
+		parts = append(parts, "modified: "+strings.Join(e.Modified, ", "))
+	}
+	if len(e.Added) != 0 {
+		parts = append(parts, "added: "+strings.Join(e.Added, ", "))
+	}
+	if len(e.Removed) != 0 {
+		parts = append(parts, "removed: "+strings.Join(e.Removed, ", "))
+	}
+	if e.Reordered {
+		parts = append(parts, "the accounts are reordered")
+	}
+	return ErrTampered.Error() + ": " + strings.Join(parts, "; ")
+}
+
+func (e *TamperError) Unwrap() error {
+	return ErrTampered
+}
+
+func computeMac(macKey []byte, v interface{}) ([]byte, error) {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(bz)
+	return mac.Sum(nil), nil
+}
+
+// manifestMac omits the empty wallets, so the manifests written before the wallets were added stay valid
+func manifestMac(macKey []byte, kdfDefaults KdfParams, m *IntegrityManifest) ([]byte, error) {
+	return computeMac(macKey, struct {
+		Kdf     KdfParams        `json:"kdf"`
+		Entries []IntegrityEntry `json:"entries"`
+		Wallets []IntegrityEntry `json:"wallets,omitempty"`
+	}{kdfDefaults, m.Entries, m.Wallets})
+}
+
+// newManifest only computes the MACs missing in macs, and adds them to macs
+func newManifest(macKey []byte, kdfDefaults KdfParams, accounts []AccountInfo, wallets []WalletInfo, macs map[string][]byte) (*IntegrityManifest, error) {
+	m := &IntegrityManifest{Entries: make([]IntegrityEntry, len(accounts))}
+	for i, acc := range accounts {
+		mac, ok := macs[acc.Address]
+		if !ok {
+			var err error
+			mac, err = computeMac(macKey, acc)
+			if err != nil {
+				return nil, err
+			}
+			macs[acc.Address] = mac
+		}
+		m.Entries[i] = IntegrityEntry{Address: acc.Address, Mac: mac}
+	}
+	// there are only a few wallets, so their MACs are not cached
+	for _, w := range wallets {
+		mac, err := computeMac(macKey, w)
+		if err != nil {
+			return nil, err
+		}
+		m.Wallets = append(m.Wallets, IntegrityEntry{Address: w.ID, Mac: mac})
+	}
+	var err error
+	m.Mac, err = manifestMac(macKey, kdfDefaults, m)
+	return m, err
+}
+
+// verifyManifest returns a *TamperError if the accounts do not match the manifest
+func verifyManifest(macKey []byte, kdfDefaults KdfParams, accounts []AccountInfo, wallets []WalletInfo, m *IntegrityManifest) error {
+	if m == nil {
+		return &TamperError{ManifestInvalid: true}
+	}
+	mac, err := manifestMac(macKey, kdfDefaults, m)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(mac, m.Mac) {
+		return &TamperError{ManifestInvalid: true}
+	}
+	expected := make(map[string][]byte, len(m.Entries))
+	for _, entry := range m.Entries {
+		expected[entry.Address] = entry.Mac
+	}
+	res := &TamperError{}
+	seen := make(map[string]bool, len(accounts))
+	var order []string
+	for _, acc := range accounts {
+		mac, ok := expected[acc.Address]
+		if !ok || seen[acc.Address] {
+			res.Added = append(res.Added, acc.Address)
+			continue
+		}
+		seen[acc.Address] = true
+		order = append(order, acc.Address)
+		accMac, err := computeMac(macKey, acc)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(accMac, mac) {
+			res.Modified = append(res.Modified, acc.Address)
+		}
+	}
+	i := 0
+	counted := make(map[string]bool, len(m.Entries))
+	for _, entry := range m.Entries {
+		if !seen[entry.Address] {
+			res.Removed = append(res.Removed, entry.Address)
+			continue
+		}
+		if counted[entry.Address] {
+			continue
+		}
+		counted[entry.Address] = true
+		if order[i] != entry.Address {
+			res.Reordered = true
+		}
+		i++
+	}
+	err = verifyWallets(macKey, wallets, m.Wallets, res)
+	if err != nil {
+		return err
+	}
+	if len(res.Modified)+len(res.Added)+len(res.Removed) == 0 && !res.Reordered {
+		return nil
+	}
+	return res
+}
+
+// verifyWallets adds the differences of the wallets to res. The order of the wallets does not matter.
+func verifyWallets(macKey []byte, wallets []WalletInfo, entries []IntegrityEntry, res *TamperError) error {
+	expected := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		expected[entry.Address] = entry.Mac
+	}
+	seen := make(map[string]bool, len(wallets))
+	for _, w := range wallets {
+		mac, ok := expected[w.ID]
+		if !ok || seen[w.ID] {
+			res.Added = append(res.Added, w.ID)
+			continue
+		}
+		seen[w.ID] = true
+		wMac, err := computeMac(macKey, w)
+		if err != nil {
+			return err
+		}
+		if !hmac.Equal(wMac, mac) {
+			res.Modified = append(res.Modified, w.ID)
+		}
+	}
+	for _, entry := range entries {
+		if !seen[entry.Address] {
+			res.Removed = append(res.Removed, entry.Address)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package keykeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testPrompt() (string, error) {
+	return "kbp", nil
+}
+
+// createProtectedKeybase creates a keybase file with a keybase passphrase and three accounts
+func createProtectedKeybase(t *testing.T, fname string) (addrs []string) {
+	kb := openTestKeybase(t, fname)
+	defer kb.Close()
+	if err := kb.SetKeybasePassphrase("", "kbp"); err != nil {
+		t.Fatal(err)
+	}
+	for i := uint32(0); i < 3; i++ {
+		acc, err := kb.CreateAccountWithPath("memo", testMnemonic, "p", HDPath{CoinType: DefaultCoinType, Index: i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, acc.Address)
+	}
+	return
+}
+
+// editKeybase changes a keybase file the way another program would
+func editKeybase(t *testing.T, fname string, edit func(kc *KeybaseContent)) {
+	content, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kc KeybaseContent
+	if err := json.Unmarshal(content, &kc); err != nil {
+		t.Fatal(err)
+	}
+	edit(&kc)
+	content, _ = json.Marshal(kc)
+	if err := ioutil.WriteFile(fname, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTamperDetection(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "integrity")
+	defer os.RemoveAll(dir)
+	for i, c := range []struct {
+		name string
+		edit func(kc *KeybaseContent, addrs []string)
+		// expected returns the error expected for the addresses of the accounts
+		expected func(addrs []string) *TamperError
+	}{
+		{"memo", func(kc *KeybaseContent, _ []string) { kc.Accounts[1].Memo = "x" },
+			func(a []string) *TamperError { return &TamperError{Modified: []string{a[1]}} }},
+		{"swapped addresses", func(kc *KeybaseContent, a []string) { kc.Accounts[0].Address, kc.Accounts[1].Address = a[1], a[0] },
+			func(a []string) *TamperError { return &TamperError{Modified: []string{a[1], a[0]}, Reordered: true} }},
+		{"added", func(kc *KeybaseContent, _ []string) {
+			acc := kc.Accounts[0]
+			acc.Address = "coinex1added"
+			kc.Accounts = append(kc.Accounts, acc)
+		}, func(a []string) *TamperError { return &TamperError{Added: []string{"coinex1added"}} }},
+		{"removed", func(kc *KeybaseContent, _ []string) { kc.Accounts = kc.Accounts[:2] },
+			func(a []string) *TamperError { return &TamperError{Removed: []string{a[2]}} }},
+		{"reordered", func(kc *KeybaseContent, _ []string) { kc.Accounts[0], kc.Accounts[2] = kc.Accounts[2], kc.Accounts[0] },
+			func(a []string) *TamperError { return &TamperError{Reordered: true} }},
+		{"manifest modified", func(kc *KeybaseContent, _ []string) { kc.Integrity.Entries[0].Mac[0] ^= 1 },
+			func(a []string) *TamperError { return &TamperError{ManifestInvalid: true} }},
+		{"manifest removed", func(kc *KeybaseContent, _ []string) { kc.Integrity = nil },
+			func(a []string) *TamperError { return &TamperError{ManifestInvalid: true} }},
+		{"protection removed", func(kc *KeybaseContent, _ []string) { kc.Secret, kc.Integrity = nil, nil },
+			func(a []string) *TamperError { return &TamperError{ProtectionRemoved: true} }},
+	} {
+		fname := filepath.Join(dir, c.name+".json")
+		addrs := createProtectedKeybase(t, fname)
+		editKeybase(t, fname, func(kc *KeybaseContent) { c.edit(kc, addrs) })
+		_, err := Open(fname, OpenOptions{Prompt: testPrompt})
+		var tamperErr *TamperError
+		if !errors.As(err, &tamperErr) || !errors.Is(err, ErrTampered) {
+			t.Fatal(i, c.name, err)
+		}
+		if expected := c.expected(addrs); !reflect.DeepEqual(tamperErr, expected) {
+			t.Fatalf("%s: %+v instead of %+v", c.name, tamperErr, expected)
+		}
+		// the accepted content is saved as legitimate
+		kb, err := Open(fname, OpenOptions{Prompt: testPrompt, AcceptTampered: true})
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		kb.Close()
+		kb, err = Open(fname, OpenOptions{Prompt: testPrompt})
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+		kb.Close()
+	}
+}
+
+func TestRemoveProtection(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "integrity")
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "kb.json")
+	createProtectedKeybase(t, fname)
+	kb, err := Open(fname, OpenOptions{Prompt: testPrompt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.SetKeybasePassphrase("kbp", ""); err != nil {
+		t.Fatal(err)
+	}
+	kb.Close()
+	kb, err = Open(fname, OpenOptions{})
+	if err != nil || kb.HasKeybasePassphrase() {
+		t.Fatal(err)
+	}
+	kb.Close()
+
+	// removing the flag along with the passphrase is not detected, which is why the GUI warns
+	fname = filepath.Join(dir, "kb2.json")
+	createProtectedKeybase(t, fname)
+	editKeybase(t, fname, func(kc *KeybaseContent) { kc.Secret, kc.Integrity, kc.Protected = nil, nil, false })
+	kb, err = Open(fname, OpenOptions{})
+	if err != nil || kb.HasKeybasePassphrase() {
+		t.Fatal(err)
+	}
+	kb.Close()
+}
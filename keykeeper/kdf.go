@@ -0,0 +1,104 @@
+package keykeeper
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	KdfScrypt     = "scrypt"
+	KdfSaltLength = 16
+)
+
+// The limits of the KDF parameters read from a keybase or a keystore file, a crafted file could otherwise
+// hang this program or exhaust its memory. Scrypt uses 128*N*R bytes of memory.
+const (
+	MaxScryptN          = 1 << 20
+	MaxScryptR          = 16
+	MaxScryptP          = 16
+	MaxScryptMemory     = 1 << 30
+	MaxPbkdf2Iterations = 10000000
+)
+
+// checkScryptParams returns an error when the parameters are over the limits
+func checkScryptParams(n, r, p int) error {
+	if n > MaxScryptN || r > MaxScryptR || p > MaxScryptP || r > 0 && n > MaxScryptMemory/128/r {
+		return fmt.Errorf("scrypt parameters n=%d r=%d p=%d are over the limits", n, r, p)
+	}
+	return nil
+}
+
+// KdfParams describes how the AES key and the checksum of an account are derived from its passphrase.
+// A nil *KdfParams stands for the legacy derivation: key=sha256(passphrase), cksum=sha256(key)
+type KdfParams struct {
+	Algo string `json:"algo"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt,omitempty"`
+}
+
+// DefaultKdfParams is used for new accounts and for upgrading legacy ones. It costs 32MB of memory
+// and about 0.1 second on a notebook. The Salt field is ignored and a fresh one is generated for each account.
+var DefaultKdfParams = KdfParams{
+	Algo: KdfScrypt,
+	N:    1 << 15,
+	R:    8,
+	P:    1,
+}
+
+// NewKdfParams copies the tunable parameters from tmpl and fills a fresh random salt
+func NewKdfParams(tmpl KdfParams) (*KdfParams, error) {
+	salt := make([]byte, KdfSaltLength)
+	if _, err := io.ReadFull(SystemRandom, salt); err != nil {
+		return nil, err
+	}
+	return &KdfParams{
+		Algo: tmpl.Algo,
+		N:    tmpl.N,
+		R:    tmpl.R,
+		P:    tmpl.P,
+		Salt: salt,
+	}, nil
+}
+
+// IsLegacy returns true if the keys are derived with the legacy single-SHA256 method
+func (p *KdfParams) IsLegacy() bool {
+	return p == nil
+}
+
+// deriveKeys returns the 32-byte AES key and the passphrase checksum stored in AccountInfo
+func (p *KdfParams) deriveKeys(passphrase string) (key, cksum []byte, err error) {
+	if p.IsLegacy() {
+		sum1 := sha256.Sum256([]byte(passphrase))
+		sum2 := sha256.Sum256(sum1[:])
+		return sum1[:], sum2[:], nil
+	}
+	if p.Algo != KdfScrypt {
+		return nil, nil, fmt.Errorf("%w: %s", ErrUnsupportedKdf, p.Algo)
+	}
+	if err := checkScryptParams(p.N, p.R, p.P); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrUnsupportedKdf, err)
+	}
+	// the first half is the AES key, and the hash of the second half is the checksum,
+	// so the checksum is as expensive to brute-force as the key itself
+	out, err := scrypt.Key([]byte(passphrase), p.Salt, p.N, p.R, p.P, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(out[32:])
+	return out[:32], sum[:], nil
+}
+
+// checkCksum derives the checksum from passphrase and compares it with the stored one in constant time
+func (p *KdfParams) checkCksum(passphrase string, cksum []byte) ([]byte, bool) {
+	key, sum, err := p.deriveKeys(passphrase)
+	if err != nil {
+		return nil, false
+	}
+	return key, subtle.ConstantTimeCompare(sum, cksum) == 1
+}
@@ -1,23 +1,17 @@
 package keykeeper
 
 import (
-	"bytes"
 	"errors"
-	"encoding/json"
-	"fmt"
 	"time"
 	"sync"
 	"io"
 	"io/ioutil"
 	"os"
-	"crypto/sha256"
 	"crypto/aes"
 	"crypto/cipher"
-	"crypto/rand"
 
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/crypto/secp256k1"
-	bip39 "github.com/cosmos/go-bip39"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/codec"
 	"github.com/cosmos/cosmos-sdk/x/auth"
@@ -29,41 +23,149 @@ const (
 )
 
 type AccountInfo struct {
-	Memo              string `json:"memo"`
-	Address           string `json:"address"`
-	PassphraseCksum   []byte `json:"passphrase_cksum"`
-	EncryptedMnemonic []byte `json:"encrypted_mnemonic"`
+	Memo              string     `json:"memo"`
+	Address           string     `json:"address"`
+	// HDPath is nil for the accounts created with DefaultHDPath before the paths were recorded
+	HDPath            *HDPath    `json:"hd_path,omitempty"`
+	// Wallet is the ID of the wallet for its child accounts, which have no mnemonic of their own
+	Wallet            string     `json:"wallet,omitempty"`
+	// KeyType is empty for the accounts holding a mnemonic, see GetKeyType
+	KeyType           string     `json:"key_type,omitempty"`
+	Kdf               *KdfParams `json:"kdf,omitempty"`
+	PassphraseCksum   []byte     `json:"passphrase_cksum"`
+	EncryptedMnemonic []byte     `json:"encrypted_mnemonic"`
+	// Language is the language of the mnemonic's wordlist, and it is empty for English
+	Language          string     `json:"language,omitempty"`
+	// EncryptedBIP39Passphrase is encrypted with the same key as EncryptedMnemonic, and it is nil when the
+	// BIP39 passphrase is empty
+	EncryptedBIP39Passphrase []byte `json:"encrypted_bip39_passphrase,omitempty"`
+	EncryptedPrivKey  []byte     `json:"encrypted_privkey,omitempty"`
 }
 
-func NewAccountInfo(memo, mnemonic, passphrase string) AccountInfo {
-	sum1 := sha256.Sum256([]byte(passphrase))
-	sum2 := sha256.Sum256(sum1[:])
-	encMnemonic, nonce := AesGcmEncrypt(sum1[:], mnemonic)
-	_, _, addr := getAllFromMnemonic(mnemonic)
-	return AccountInfo{
-		Memo:              memo,
-		Address:           addr,
-		PassphraseCksum:   sum2[:],
-		EncryptedMnemonic: append(nonce, []byte(encMnemonic)...),
+func NewAccountInfo(memo, mnemonic, passphrase string) (AccountInfo, error) {
+	return NewAccountInfoWithKdf(memo, mnemonic, passphrase, DefaultKdfParams)
+}
+
+// NewAccountInfoWithKdf is like NewAccountInfo, but uses the given KDF parameters with a fresh salt
+func NewAccountInfoWithKdf(memo, mnemonic, passphrase string, kdfTmpl KdfParams) (AccountInfo, error) {
+	return NewAccountInfoWithPath(memo, mnemonic, passphrase, DefaultHDPath, kdfTmpl)
+}
+
+// NewAccountInfoWithPath derives the account's key from mnemonic with path, which is recorded in the account
+func NewAccountInfoWithPath(memo, mnemonic, passphrase string, path HDPath, kdfTmpl KdfParams) (AccountInfo, error) {
+	key := MnemonicKey{Mnemonic: mnemonic, Path: path}
+	if err := validateKey(key); err != nil {
+		return AccountInfo{}, err
+	}
+	return NewAccountInfoWithKey(memo, key, passphrase, kdfTmpl)
+}
+
+// encryptSecret encrypts secret with the key derived from passphrase by a new KdfParams copied from kdfTmpl.
+// The returned ciphertext is prefixed with its nonce.
+func encryptSecret(secret, passphrase string, kdfTmpl KdfParams) (kdf *KdfParams, cksum, ciphertext []byte, err error) {
+	kdf, cksum, ciphertexts, err := encryptSecrets(passphrase, kdfTmpl, secret)
+	if err != nil {
+		return
+	}
+	return kdf, cksum, ciphertexts[0], nil
+}
+
+// encryptSecrets is like encryptSecret, but encrypts several secrets with one key, each with its own nonce.
+// An empty secret gets a nil ciphertext.
+func encryptSecrets(passphrase string, kdfTmpl KdfParams, secrets ...string) (kdf *KdfParams, cksum []byte, ciphertexts [][]byte, err error) {
+	kdf, err = NewKdfParams(kdfTmpl)
+	if err != nil {
+		return
+	}
+	key, cksum, err := kdf.deriveKeys(passphrase)
+	if err != nil {
+		return
+	}
+	ciphertexts = make([][]byte, len(secrets))
+	for i, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		encrypted, nonce, err := AesGcmEncrypt(key, secret)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ciphertexts[i] = append(nonce, encrypted...)
+	}
+	return
+}
+
+// decryptSecret returns ErrBadPassphrase or ErrCorruptedAccount, which should be wrapped by the caller
+func decryptSecret(kdf *KdfParams, cksum, ciphertext []byte, passphrase string) (string, error) {
+	if ciphertext == nil {
+		return "", ErrCorruptedAccount
+	}
+	secrets, err := decryptSecrets(kdf, cksum, passphrase, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return secrets[0], nil
+}
+
+// decryptSecrets decrypts the ciphertexts from encryptSecrets, a nil ciphertext gets an empty secret
+func decryptSecrets(kdf *KdfParams, cksum []byte, passphrase string, ciphertexts ...[]byte) ([]string, error) {
+	key, ok := kdf.checkCksum(passphrase, cksum)
+	if !ok {
+		return nil, ErrBadPassphrase
+	}
+	secrets := make([]string, len(ciphertexts))
+	for i, ciphertext := range ciphertexts {
+		if ciphertext == nil {
+			continue
+		}
+		if len(ciphertext) < AesNonceLength {
+			return nil, ErrCorruptedAccount
+		}
+		secret, err := AesGcmDecrypt(key, ciphertext[AesNonceLength:], ciphertext[:AesNonceLength])
+		if err != nil {
+			// the checksum matches, so it is the ciphertext that went wrong
+			return nil, ErrCorruptedAccount
+		}
+		secrets[i] = secret
 	}
+	return secrets, nil
+}
+
+// Path returns the HD path used to derive the account's key
+func (acc AccountInfo) Path() HDPath {
+	if acc.HDPath == nil {
+		return DefaultHDPath
+	}
+	return *acc.HDPath
 }
 
 func (acc AccountInfo) CheckPassphrase(passphrase string) error {
-	sum1 := sha256.Sum256([]byte(passphrase))
-	sum2 := sha256.Sum256(sum1[:])
-	if !bytes.Equal(sum2[:], acc.EncryptedMnemonic) {
-		return errors.New("Passphrase's checksum does not match")
+	if _, ok := acc.Kdf.checkCksum(passphrase, acc.PassphraseCksum); !ok {
+		return accountError(acc.Address, ErrBadPassphrase)
 	}
 	return nil
 }
 
-func getAllFromMnemonic(mnemonic string) (privk secp256k1.PrivKeySecp256k1, pubk secp256k1.PubKeySecp256k1, addr string) {
-	seed := bip39.NewSeed(mnemonic, DefaultBIP39Passphrase)
-	fullHdPath := hd.NewFundraiserParams(0, DefaultCoinType, 0) //account=0 addressIdx=0
+func (acc AccountInfo) decryptMnemonicKey(passphrase string) (MnemonicKey, error) {
+	if acc.EncryptedMnemonic == nil {
+		return MnemonicKey{}, accountError(acc.Address, ErrCorruptedAccount)
+	}
+	secrets, err := decryptSecrets(acc.Kdf, acc.PassphraseCksum, passphrase, acc.EncryptedMnemonic, acc.EncryptedBIP39Passphrase)
+	if err != nil {
+		return MnemonicKey{}, accountError(acc.Address, err)
+	}
+	return MnemonicKey{Mnemonic: secrets[0], Path: acc.Path(), BIP39Passphrase: secrets[1], Language: acc.Language}, nil
+}
+
+// getAllFromMnemonic derives the key with the seed of the NFKD mnemonic and the BIP39 passphrase, which is usually
+// empty. It does not need the mnemonic's wordlist, which is checked by validateKey when the mnemonic is added.
+func getAllFromMnemonic(key MnemonicKey) (privk secp256k1.PrivKeySecp256k1, pubk secp256k1.PubKeySecp256k1, addr string, err error) {
+	seed := mnemonicSeed(key.Mnemonic, key.BIP39Passphrase)
+	fullHdPath := key.Path.params()
 	masterPriv, ch := hd.ComputeMastersFromSeed(seed)
 	derivedPriv, err := hd.DerivePrivateKeyForPath(masterPriv, ch, fullHdPath.String())
 	if err != nil {
-		panic(err)
+		return
 	}
 	privk = secp256k1.PrivKeySecp256k1(derivedPriv)
 	pubk = privk.PubKey().(secp256k1.PubKeySecp256k1)
@@ -74,9 +176,24 @@ func getAllFromMnemonic(mnemonic string) (privk secp256k1.PrivKeySecp256k1, pubk
 
 type MyKeyBase struct {
 	mtx              sync.RWMutex
-	openedFile       *os.File
+	storage          Storage
+	header           KeybaseHeader
+	key              *keybaseKey
+	sealed           bool
+	readOnly         bool
+	locked           bool
 	cachedPassphrase map[string]string
-	Accounts         []AccountInfo
+	// Accounts keeps the order of creation, and index maps an address to its position in Accounts
+	Accounts []AccountInfo
+	index    map[string]int
+	// changed holds the addresses added, modified or deleted since the last Save, for IncrementalStorage.
+	// allChanged means the whole keybase must be stored, e.g. after it is sealed or unsealed.
+	changed    map[string]bool
+	allChanged bool
+	// macs caches the MAC of each account for the integrity manifest, under the current key
+	macs map[string][]byte
+	// wallets are few, so they are always stored along with the keybase header
+	wallets []WalletInfo
 }
 
 func (kb *MyKeyBase) GetCachedPassphrase(addr string) (res string, ok bool) {
@@ -86,25 +203,36 @@ func (kb *MyKeyBase) GetCachedPassphrase(addr string) (res string, ok bool) {
 	return
 }
 
+// AddCachedPassphrase remembers the passphrase of an account for 5 minutes, after checking it.
+// A legacy account is upgraded to the keybase's default KDF at the same time.
 func (kb *MyKeyBase) AddCachedPassphrase(addr, passphrase string) error {
-	kb.mtx.RLock()
-	defer kb.mtx.RUnlock()
+	err := kb.cachePassphrase(addr, passphrase)
+	if err != nil {
+		return err
+	}
+	_, err = kb.UpgradeKdf(addr, passphrase)
+	return err
+}
+
+func (kb *MyKeyBase) cachePassphrase(addr, passphrase string) error {
 	accInfo, ok := kb.GetAccountInfo(addr)
 	if !ok {
-		return errors.New("No such account")
+		return accountError(addr, ErrNoSuchAccount)
 	}
-	err := accInfo.CheckPassphrase(passphrase)
+	err := kb.checkPassphrase(accInfo, passphrase)
 	if err != nil {
 		return err
 	}
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
 	oldPass, ok := kb.cachedPassphrase[addr]
-	if ok && oldPass  == passphrase {
+	if ok && oldPass == passphrase {
 		return nil
 	}
 	kb.cachedPassphrase[addr] = passphrase
 
 	// delete the cached passphrase after 5 minutes
-	timer := time.NewTimer(time.Second*5*60)
+	timer := time.NewTimer(time.Second * 5 * 60)
 	go func() {
 		<-timer.C
 		kb.mtx.Lock()
@@ -114,116 +242,521 @@ func (kb *MyKeyBase) AddCachedPassphrase(addr, passphrase string) error {
 	return nil
 }
 
-func (kb *MyKeyBase) AddAccount(accInfo AccountInfo) {
+func (kb *MyKeyBase) AddAccount(accInfo AccountInfo) error {
 	kb.mtx.Lock()
 	defer kb.mtx.Unlock()
-	for i := range kb.Accounts {
-		if accInfo.Address == kb.Accounts[i].Address {
-			kb.Accounts[i] = accInfo
-			return
-		}
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	kb.markChanged(accInfo.Address)
+	if i, ok := kb.index[accInfo.Address]; ok {
+		kb.Accounts[i] = accInfo
+		return nil
 	}
+	kb.index[accInfo.Address] = len(kb.Accounts)
 	kb.Accounts = append(kb.Accounts, accInfo)
+	return nil
+}
+
+// markChanged must be called with kb.mtx held
+func (kb *MyKeyBase) markChanged(addr string) {
+	kb.changed[addr] = true
+	delete(kb.macs, addr)
 }
 
-func (kb *MyKeyBase) DeleteAccount(addr string) {
+// markAllChanged must be called with kb.mtx held, after the key or the sealing changes
+func (kb *MyKeyBase) markAllChanged() {
+	kb.allChanged = true
+	kb.macs = make(map[string][]byte)
+}
+
+func (kb *MyKeyBase) deleteAccount(addr string) error {
 	kb.mtx.Lock()
 	defer kb.mtx.Unlock()
-	if len(kb.Accounts) == 0 {
-		return
-	}
-	idx := -1
-	for i, acc := range kb.Accounts {
-		if acc.Address == addr {
-			idx = i
-			break
-		}
+	if kb.readOnly {
+		return ErrReadOnly
 	}
-	if idx == -1 {
+	kb.removeAccount(addr)
+	return nil
+}
+
+// removeAccount must be called with kb.mtx held, on a keybase which is not read-only
+func (kb *MyKeyBase) removeAccount(addr string) {
+	idx, ok := kb.index[addr]
+	if !ok {
 		return
 	}
-	if idx != len(kb.Accounts)-1 {
-		kb.Accounts[idx] = kb.Accounts[len(kb.Accounts)-1]
-	}
+	// shift the following accounts instead of moving the last one here, to keep the order.
+	// It costs O(n), which is small compared to checking the passphrase before deleting.
+	kb.markChanged(addr)
+	delete(kb.index, addr)
+	copy(kb.Accounts[idx:], kb.Accounts[idx+1:])
+	kb.Accounts[len(kb.Accounts)-1] = AccountInfo{}
 	kb.Accounts = kb.Accounts[:len(kb.Accounts)-1]
+	for i := idx; i < len(kb.Accounts); i++ {
+		kb.index[kb.Accounts[i].Address] = i
+	}
 }
 
 func (kb *MyKeyBase) GetAccountInfo(addr string) (AccountInfo, bool) {
 	kb.mtx.RLock()
 	defer kb.mtx.RUnlock()
-	for _, acc := range kb.Accounts {
-		if acc.Address == addr {
-			return acc, true
-		}
+	if i, ok := kb.index[addr]; ok {
+		return kb.Accounts[i], true
 	}
 	return AccountInfo{}, false
 }
 
-func (kb *MyKeyBase) Init(openedFile *os.File, accounts []AccountInfo) {
+func (kb *MyKeyBase) Init(storage Storage, header KeybaseHeader, accounts []AccountInfo) {
+	kb.initLoaded(storage, OpenReadWrite, loadedKeybase{header: header, accounts: accounts})
+}
+
+func (kb *MyKeyBase) initLoaded(storage Storage, mode OpenMode, lk loadedKeybase) {
 	kb.mtx.Lock()
 	defer kb.mtx.Unlock()
-	kb.openedFile = openedFile
-	kb.Accounts = accounts
+	kb.storage = storage
+	kb.readOnly = mode == OpenReadOnly
+	kb.header = lk.header
+	kb.Accounts = lk.accounts
+	kb.wallets = lk.wallets
+	kb.key = lk.key
+	kb.sealed = lk.sealed
 	kb.cachedPassphrase = make(map[string]string)
+	kb.buildIndex()
+	kb.changed = make(map[string]bool)
+	kb.allChanged = false
+	kb.macs = lk.macs()
+}
+
+// buildIndex must be called with kb.mtx held. When an address appears more than once in a tampered
+// keybase, the first account is used.
+func (kb *MyKeyBase) buildIndex() {
+	kb.index = make(map[string]int, len(kb.Accounts))
+	for i := len(kb.Accounts) - 1; i >= 0; i-- {
+		kb.index[kb.Accounts[i].Address] = i
+	}
 }
 
 func (kb *MyKeyBase) ChangePassphrase(addr, oldPassphrase, newPassphrase string) error {
+	if kb.IsReadOnly() {
+		return ErrReadOnly
+	}
 	accInfo, ok := kb.GetAccountInfo(addr)
 	if !ok {
-		return errors.New("No such address")
+		return accountError(addr, ErrNoSuchAccount)
+	}
+	if accInfo.Wallet != "" {
+		// the children of a wallet share its passphrase
+		return kb.ChangeWalletPassphrase(accInfo.Wallet, oldPassphrase, newPassphrase)
 	}
-	err := accInfo.CheckPassphrase(oldPassphrase)
+	key, err := accInfo.decryptKey(oldPassphrase)
 	if err != nil {
-		return errors.New("Old passphrase is incorrect")
+		return err
 	}
-	ciphertext := accInfo.EncryptedMnemonic[AesNonceLength:]
-	nonce := accInfo.EncryptedMnemonic[:AesNonceLength]
-	sum1 := sha256.Sum256([]byte(oldPassphrase))
-	mnemonic := AesGcmDecrypt(sum1[:], ciphertext, nonce)
-	accInfo = NewAccountInfo(accInfo.Memo, mnemonic, newPassphrase)
-	kb.AddAccount(accInfo)
-	return nil
+	accInfo, err = NewAccountInfoWithKey(accInfo.Memo, key, newPassphrase, kb.Header().Kdf)
+	if err != nil {
+		return err
+	}
+	err = kb.AddAccount(accInfo)
+	if err != nil {
+		return err
+	}
+	return kb.Save()
 }
 
 func (kb *MyKeyBase) GetMnemonic(addr, passphrase string) (string, error) {
+	key, err := kb.GetMnemonicKey(addr, passphrase)
+	return key.Mnemonic, err
+}
+
+// GetMnemonicKey returns the mnemonic of an account along with its BIP39 passphrase and its HD path,
+// which are all needed to restore the account elsewhere
+func (kb *MyKeyBase) GetMnemonicKey(addr, passphrase string) (MnemonicKey, error) {
 	accInfo, ok := kb.GetAccountInfo(addr)
 	if !ok {
-		return "", errors.New("No such account")
+		return MnemonicKey{}, accountError(addr, ErrNoSuchAccount)
+	}
+	return kb.mnemonicKeyOf(accInfo, passphrase)
+}
+
+func (kb *MyKeyBase) HasAccount(addr string) bool {
+	_, ok := kb.GetAccountInfo(addr)
+	return ok
+}
+
+// CreateAccount adds an account with DefaultHDPath and the default KDF parameters of this keybase, and saves it
+func (kb *MyKeyBase) CreateAccount(memo, mnemonic, passphrase string) (AccountInfo, error) {
+	return kb.CreateAccountWithPath(memo, mnemonic, passphrase, DefaultHDPath)
+}
+
+// CreateAccountWithPath is like CreateAccount, but derives the account's key with path
+func (kb *MyKeyBase) CreateAccountWithPath(memo, mnemonic, passphrase string, path HDPath) (AccountInfo, error) {
+	return kb.CreateAccountWithKey(memo, MnemonicKey{Mnemonic: mnemonic, Path: path}, passphrase)
+}
+
+// CreateAccountWithKey is like CreateAccount, but the account holds key, e.g. a MnemonicKey with a BIP39 passphrase
+func (kb *MyKeyBase) CreateAccountWithKey(memo string, key KeyMaterial, passphrase string) (AccountInfo, error) {
+	if err := validateKey(key); err != nil {
+		return AccountInfo{}, err
+	}
+	accInfo, err := NewAccountInfoWithKey(memo, key, passphrase, kb.Header().Kdf)
+	if err != nil {
+		return accInfo, err
+	}
+	err = kb.AddAccount(accInfo)
+	if err != nil {
+		return accInfo, err
+	}
+	err = kb.Save()
+	return accInfo, err
+}
+
+// DeleteAccount removes an account after checking its passphrase, and saves the keybase.
+// Deleting a child does not change its wallet, so the child's index is not reused.
+func (kb *MyKeyBase) DeleteAccount(addr, passphrase string) error {
+	accInfo, ok := kb.GetAccountInfo(addr)
+	if !ok {
+		return accountError(addr, ErrNoSuchAccount)
+	}
+	err := kb.checkPassphrase(accInfo, passphrase)
+	if err != nil {
+		return err
+	}
+	err = kb.deleteAccount(addr)
+	if err != nil {
+		return err
+	}
+	return kb.Save()
+}
+
+// NewAccountInfo creates an account with DefaultHDPath and the default KDF parameters of this keybase
+func (kb *MyKeyBase) NewAccountInfo(memo, mnemonic, passphrase string) (AccountInfo, error) {
+	return kb.NewAccountInfoWithPath(memo, mnemonic, passphrase, DefaultHDPath)
+}
+
+func (kb *MyKeyBase) NewAccountInfoWithPath(memo, mnemonic, passphrase string, path HDPath) (AccountInfo, error) {
+	return NewAccountInfoWithPath(memo, mnemonic, passphrase, path, kb.Header().Kdf)
+}
+
+// UpgradeKdf re-encrypts a legacy account with the keybase's default KDF if the passphrase is correct,
+// and then saves the keybase. It returns true if the account was upgraded.
+// A read-only keybase and the children of wallets, which are never legacy, are not upgraded.
+func (kb *MyKeyBase) UpgradeKdf(addr, passphrase string) (bool, error) {
+	accInfo, ok := kb.GetAccountInfo(addr)
+	if !ok {
+		return false, accountError(addr, ErrNoSuchAccount)
+	}
+	if accInfo.Wallet != "" || !accInfo.Kdf.IsLegacy() || kb.IsReadOnly() {
+		return false, nil
+	}
+	key, err := accInfo.decryptKey(passphrase)
+	if err != nil {
+		return false, err
+	}
+	accInfo, err = NewAccountInfoWithKey(accInfo.Memo, key, passphrase, kb.Header().Kdf)
+	if err != nil {
+		return false, err
 	}
-	ciphertext := accInfo.EncryptedMnemonic[AesNonceLength:]
-	nonce := accInfo.EncryptedMnemonic[:AesNonceLength]
-	sum1 := sha256.Sum256([]byte(passphrase))
-	mnemonic := AesGcmDecrypt(sum1[:], ciphertext, nonce)
-	return mnemonic, nil
+	err = kb.AddAccount(accInfo)
+	if err != nil {
+		return false, err
+	}
+	return true, kb.Save()
+}
+
+// Sign signs msg with the account's private key and returns the JSON of auth.StdSignature.
+// A legacy account is upgraded before signing.
+func (kb *MyKeyBase) Sign(addr, passphrase string, msg []byte) (string, error) {
+	_, err := kb.UpgradeKdf(addr, passphrase)
+	if err != nil {
+		return "", err
+	}
+	sig, pub, err := kb.signBytes(addr, passphrase, msg)
+	if err != nil {
+		return "", err
+	}
+	stdSign := auth.StdSignature{PubKey: pub, Signature: sig}
+	out, err := gCdc.MarshalJSON(stdSign)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
 }
 
-func (kb *MyKeyBase) Sign(addr, passphrase string, msg []byte) (sig []byte, pubk secp256k1.PubKeySecp256k1, err error) {
-	mnemonic, err := kb.GetMnemonic(addr, passphrase)
-	privk, pubk, _ := getAllFromMnemonic(mnemonic)
+func (kb *MyKeyBase) signBytes(addr, passphrase string, msg []byte) (sig []byte, pubk secp256k1.PubKeySecp256k1, err error) {
+	privk, err := kb.privKeyOf(addr, passphrase)
+	if err != nil {
+		return
+	}
+	pubk = privk.PubKey().(secp256k1.PubKeySecp256k1)
 	sig, err = privk.Sign(msg)
 	return
 }
 
+// Save stores the keybase into its storage. A FileStorage writes a temporary file and then renames it
+// over the keybase file, keeping the previous content as a backup. An IncrementalStorage only writes
+// the accounts changed since the last Save. The lock is held while storing, so the saves are in order.
 func (kb *MyKeyBase) Save() error {
-	err := kb.openedFile.Truncate(0)
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.storage == nil {
+		return ErrNotOpen
+	}
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	kf, err := kb.toContent()
 	if err != nil {
 		return err
 	}
-	b, err := json.Marshal(kb.Accounts)
+	if is, ok := kb.storage.(IncrementalStorage); ok && !kb.allChanged {
+		err = is.StoreChanges(kf, kb.changed)
+	} else {
+		err = kb.storage.Store(kf)
+	}
 	if err != nil {
 		return err
 	}
-	_, err = kb.openedFile.Write(b)
+	kb.changed = make(map[string]bool)
+	kb.allChanged = false
+	return nil
+}
+
+// toContent must be called with kb.mtx held
+func (kb *MyKeyBase) toContent() (kf KeybaseContent, err error) {
+	kf.KeybaseHeader = kb.header
+	kf.Protected = kb.key != nil
+	if kb.key == nil {
+		kf.Accounts, kf.Wallets = kb.Accounts, kb.wallets
+		return
+	}
+	kf.Secret = &kb.key.secret
+	macs := kb.macs
+	if len(kb.index) != len(kb.Accounts) {
+		// the cache is keyed by address, so it can not be used for duplicated addresses
+		macs = make(map[string][]byte)
+	}
+	manifest, err := newManifest(kb.key.macKey(), kb.header.Kdf, kb.Accounts, kb.wallets, macs)
+	if err != nil {
+		return
+	}
+	if kb.sealed {
+		kf.Sealed, err = kb.key.seal(sealedPayload{Accounts: kb.Accounts, Wallets: kb.wallets, Integrity: manifest})
+	} else {
+		kf.Accounts, kf.Wallets, kf.Integrity = kb.Accounts, kb.wallets, manifest
+	}
+	return
+}
+
+func (kb *MyKeyBase) IsSealed() bool {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return kb.sealed
+}
+
+func (kb *MyKeyBase) HasKeybasePassphrase() bool {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return kb.key != nil
+}
+
+// SetKeybasePassphrase sets, changes or removes (when newPassphrase is empty) the keybase-level passphrase,
+// which protects the integrity of the accounts and is used to seal the keybase.
+// oldPassphrase is ignored if the keybase does not have a passphrase yet. The keybase is saved then.
+func (kb *MyKeyBase) SetKeybasePassphrase(oldPassphrase, newPassphrase string) error {
+	err := kb.setKeybasePassphrase(oldPassphrase, newPassphrase)
+	if err != nil {
+		return err
+	}
+	return kb.Save()
+}
+
+func (kb *MyKeyBase) setKeybasePassphrase(oldPassphrase, newPassphrase string) error {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	// a sealed keybase always keeps a non-empty passphrase
+	if len(newPassphrase) == 0 && kb.sealed {
+		return ErrUnsealFirst
+	}
+	if kb.key != nil {
+		if err := kb.key.check(oldPassphrase); err != nil {
+			return err
+		}
+	}
+	if len(newPassphrase) == 0 {
+		kb.key = nil
+		kb.macs = make(map[string][]byte)
+		return nil
+	}
+	key, err := newKeybaseKey(newPassphrase, kb.header.Kdf)
+	if err != nil {
+		return err
+	}
+	kb.key = key
+	kb.macs = make(map[string][]byte)
+	return nil
+}
+
+// Seal encrypts the whole account list with the keybase-level passphrase and saves the keybase.
+// If the keybase does not have one yet, passphrase becomes its keybase-level passphrase.
+// The backups made before are removed, because they still contain the addresses and memos in plaintext.
+func (kb *MyKeyBase) Seal(passphrase string) error {
+	err := kb.setSealed(passphrase)
+	if err != nil {
+		return err
+	}
+	err = kb.Save()
+	if err != nil {
+		return err
+	}
+	return kb.RemoveBackups()
+}
+
+func (kb *MyKeyBase) setSealed(passphrase string) error {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	if kb.sealed {
+		return ErrAlreadySealed
+	}
+	if len(passphrase) == 0 {
+		return ErrEmptyPassphrase
+	}
+	if kb.key != nil {
+		if err := kb.key.check(passphrase); err != nil {
+			return err
+		}
+	} else {
+		key, err := newKeybaseKey(passphrase, kb.header.Kdf)
+		if err != nil {
+			return err
+		}
+		kb.key = key
+	}
+	kb.sealed = true
+	kb.markAllChanged()
+	return nil
+}
+
+// Unseal saves the account list in plaintext again, after checking the keybase-level passphrase.
+// The passphrase is kept for the integrity manifest, use SetKeybasePassphrase to remove it.
+func (kb *MyKeyBase) Unseal(passphrase string) error {
+	err := kb.setUnsealed(passphrase)
 	if err != nil {
 		return err
 	}
-	return kb.openedFile.Sync()
+	return kb.Save()
+}
+
+func (kb *MyKeyBase) setUnsealed(passphrase string) error {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	if !kb.sealed {
+		return ErrNotSealed
+	}
+	if err := kb.key.check(passphrase); err != nil {
+		return err
+	}
+	kb.sealed = false
+	kb.allChanged = true
+	return nil
+}
+
+// backupStorage returns nil if the storage does not keep backups
+func (kb *MyKeyBase) backupStorage() BackupStorage {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	bs, _ := kb.storage.(BackupStorage)
+	return bs
+}
+
+// SetBackupGenerations sets how many backups are kept by Save, zero disables backups.
+// It must be called after opening, and is ignored by storages without backups.
+func (kb *MyKeyBase) SetBackupGenerations(n int) {
+	if bs := kb.backupStorage(); bs != nil {
+		bs.SetBackupGenerations(n)
+	}
+}
+
+func (kb *MyKeyBase) ListBackups() ([]BackupInfo, error) {
+	bs := kb.backupStorage()
+	if bs == nil {
+		return nil, nil
+	}
+	return bs.ListBackups()
+}
+
+func (kb *MyKeyBase) RemoveBackups() error {
+	if kb.IsReadOnly() {
+		return ErrReadOnly
+	}
+	bs := kb.backupStorage()
+	if bs == nil {
+		return nil
+	}
+	return bs.RemoveBackups()
+}
+
+// RestoreBackup replaces the keybase's content with one of its backups, which are single-JSON keybase files.
+// With a FileStorage, the current content is kept as a new backup, so a restoration can be undone. prompt is only called for backups with
+// a keybase passphrase, and a backup which fails the integrity check is refused.
+func (kb *MyKeyBase) RestoreBackup(backupPath string, prompt PassphrasePrompt) error {
+	if kb.IsReadOnly() {
+		return ErrReadOnly
+	}
+	content, err := ioutil.ReadFile(backupPath)
+	if err != nil {
+		return err
+	}
+	lk, err := loadKeybase(content, prompt)
+	if err != nil {
+		return err
+	}
+	kb.mtx.Lock()
+	kb.header = lk.header
+	kb.Accounts = lk.accounts
+	kb.wallets = lk.wallets
+	kb.key = lk.key
+	kb.sealed = lk.sealed
+	kb.cachedPassphrase = make(map[string]string)
+	kb.buildIndex()
+	kb.markAllChanged()
+	kb.mtx.Unlock()
+	return kb.Save()
+}
+
+func (kb *MyKeyBase) Header() KeybaseHeader {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return kb.header
 }
 
 func (kb *MyKeyBase) IsOpen() bool {
 	kb.mtx.RLock()
 	defer kb.mtx.RUnlock()
-	return kb.openedFile != nil
+	return kb.storage != nil
+}
+
+// Storage returns nil if the keybase is not opened
+func (kb *MyKeyBase) Storage() Storage {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return kb.storage
+}
+
+// IsReadOnly returns true if the keybase was opened with OpenReadOnly
+func (kb *MyKeyBase) IsReadOnly() bool {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return kb.readOnly
 }
 
 func (kb *MyKeyBase) GetStringItems() (items []string) {
@@ -238,13 +771,23 @@ func (kb *MyKeyBase) GetStringItems() (items []string) {
 func (kb *MyKeyBase) Close() {
 	kb.mtx.Lock()
 	defer kb.mtx.Unlock()
-	if kb.openedFile != nil {
-		kb.openedFile.Close()
-		kb.openedFile = nil
+	if kb.locked {
+		kb.storage.Unlock()
+		kb.locked = false
 	}
+	kb.storage = nil
+	kb.readOnly = false
+}
+
+// holdLock makes Close unlock the storage
+func (kb *MyKeyBase) holdLock() {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	kb.locked = true
 }
 
 // ================================================
+// KB is the default instance used by the package-level functions below
 var KB MyKeyBase
 
 func CloseKeybase() {
@@ -255,10 +798,18 @@ func KeybaseOpened() bool {
 	return KB.IsOpen()
 }
 
+func KeybaseReadOnly() bool {
+	return KB.IsReadOnly()
+}
+
 func GetMnemonic(addr, passphrase string) (string, error) {
 	return KB.GetMnemonic(addr, passphrase)
 }
 
+func GetMnemonicKey(addr, passphrase string) (MnemonicKey, error) {
+	return KB.GetMnemonicKey(addr, passphrase)
+}
+
 func GetCachedPassphrase(addr string) (res string, ok bool) {
 	return KB.GetCachedPassphrase(addr)
 }
@@ -268,96 +819,156 @@ func AddCachedPassphrase(addr, passphrase string) error {
 }
 
 func HasAccount(addr string) bool {
-	_, ok := KB.GetAccountInfo(addr)
-	return ok
+	return KB.HasAccount(addr)
 }
 
-func OpenKeybase(fname string) error {
-	KB.Close()
-	info, err := os.Stat(fname)
-	fileNotExists := os.IsNotExist(err)
-	fmt.Printf("1 %#v\n", err)
-	if err != nil && !fileNotExists {
+type OpenMode int
+
+const (
+	OpenReadWrite OpenMode = iota
+	// OpenReadOnly opens an existing keybase without locking it, and all the changes are refused
+	// with ErrReadOnly. Accounts can still be used for signing.
+	OpenReadOnly
+)
+
+// OpenOptions controls how OpenKeybase opens a keybase
+type OpenOptions struct {
+	Mode OpenMode
+	// Prompt asks for the keybase-level passphrase, it is only called when the keybase has one,
+	// before any account is loaded
+	Prompt PassphrasePrompt
+	// AcceptTampered opens the keybase even if it fails the integrity check, and then saves it,
+	// so that its current content becomes the legitimate one. A read-only keybase is not saved.
+	AcceptTampered bool
+}
+
+// Open opens the keybase at location, which chooses the storage as NewStorage does
+func (kb *MyKeyBase) Open(location string, opts OpenOptions) error {
+	storage, err := NewStorage(location)
+	if err != nil {
 		return err
 	}
-	if info != nil && info.IsDir() {
-		return errors.New(fname+" is not a plain file")
-	}
-	if fileNotExists { //So a new empty file is created
-		f, err := os.Create(fname)
+	return kb.OpenStorage(storage, opts)
+}
+
+// OpenStorage opens the keybase in storage, or creates it when nothing has been stored.
+// The keybase opened before is closed. If the accounts do not match the integrity manifest,
+// a *TamperError is returned.
+func (kb *MyKeyBase) OpenStorage(storage Storage, opts OpenOptions) error {
+	kb.Close()
+	if opts.Mode == OpenReadOnly {
+		// no lock is needed, because the storages replace their files atomically and we never write them
+		err := kb.load(storage, opts)
 		if err != nil {
-			return err
+			kb.Close()
 		}
-		f.Close()
+		return err
 	}
-	openedFile, err := os.OpenFile(fname, os.O_RDWR, 0644)
-	fmt.Printf("2 %#v\n", err)
+	// the lock is taken before loading, so no other process can save between our loading and saving
+	err := storage.Lock()
 	if err != nil {
 		return err
 	}
-	if fileNotExists {
-		KB.Init(openedFile, []AccountInfo{})
-		return nil
+	err = kb.load(storage, opts)
+	if err != nil {
+		kb.Close()
+		storage.Unlock()
+		return err
 	}
-	content, err := ioutil.ReadAll(openedFile)
+	kb.holdLock()
+	return nil
+}
+
+func (kb *MyKeyBase) load(storage Storage, opts OpenOptions) error {
+	kc, exists, err := storage.Load()
 	if err != nil {
-		KB.openedFile = nil
-		openedFile.Close()
 		return err
 	}
-	var accounts []AccountInfo
-	err = json.Unmarshal(content, &accounts)
+	if !exists {
+		if opts.Mode == OpenReadOnly {
+			return &os.PathError{Op: "open", Path: storage.String(), Err: os.ErrNotExist}
+		}
+		//So a new empty keybase is created
+		kb.Init(storage, NewKeybaseHeader(), []AccountInfo{})
+		return kb.Save()
+	}
+	lk, err := loadContent(kc, opts.Prompt)
+	var tamperErr *TamperError
+	if errors.As(err, &tamperErr) && opts.AcceptTampered {
+		kb.initLoaded(storage, opts.Mode, lk)
+		if opts.Mode == OpenReadOnly {
+			return nil
+		}
+		return kb.Save()
+	}
 	if err != nil {
-		KB.openedFile = nil
-		openedFile.Close()
 		return err
 	}
-	KB.Init(openedFile, accounts)
+	kb.initLoaded(storage, opts.Mode, lk)
 	return nil
 }
 
+// OpenKeybase opens the keybase at location with the default instance KB, see NewStorage for the locations
+func OpenKeybase(location string, opts OpenOptions) error {
+	return KB.Open(location, opts)
+}
+
+func SetBackupGenerations(n int) {
+	KB.SetBackupGenerations(n)
+}
+
+func ListKeybaseBackups() ([]BackupInfo, error) {
+	return KB.ListBackups()
+}
+
+func RestoreKeybaseBackup(backupPath string, prompt PassphrasePrompt) error {
+	return KB.RestoreBackup(backupPath, prompt)
+}
+
+func KeybaseSealed() bool {
+	return KB.IsSealed()
+}
+
+func HasKeybasePassphrase() bool {
+	return KB.HasKeybasePassphrase()
+}
+
+func SetKeybasePassphrase(oldPassphrase, newPassphrase string) error {
+	return KB.SetKeybasePassphrase(oldPassphrase, newPassphrase)
+}
+
+func SealKeybase(passphrase string) error {
+	return KB.Seal(passphrase)
+}
+
+func UnsealKeybase(passphrase string) error {
+	return KB.Unseal(passphrase)
+}
+
 func CreateAccount(memo, mnemonic, passphrase string) (AccountInfo, error) {
-	accInfo := NewAccountInfo(memo, mnemonic, passphrase)
-	KB.AddAccount(accInfo)
-	err := KB.Save()
-	return accInfo, err
+	return KB.CreateAccount(memo, mnemonic, passphrase)
+}
+
+func CreateAccountWithPath(memo, mnemonic, passphrase string, path HDPath) (AccountInfo, error) {
+	return KB.CreateAccountWithPath(memo, mnemonic, passphrase, path)
+}
+
+func CreateAccountWithKey(memo string, key KeyMaterial, passphrase string) (AccountInfo, error) {
+	return KB.CreateAccountWithKey(memo, key, passphrase)
 }
 
 func ChangePassphrase(addr, oldPassphrase, newPassphrase string) error {
-	err := KB.ChangePassphrase(addr, oldPassphrase, newPassphrase)
-	if err != nil {
-		return err
-	}
-	return KB.Save()
+	return KB.ChangePassphrase(addr, oldPassphrase, newPassphrase)
 }
 
 func DeleteAccount(addr, passphrase string) error {
-	accInfo, ok := KB.GetAccountInfo(addr)
-	if !ok {
-		return errors.New("No such account")
-	}
-	err := accInfo.CheckPassphrase(passphrase)
-	if err != nil {
-		return err
-	}
-	KB.DeleteAccount(addr)
-	return KB.Save()
+	return KB.DeleteAccount(addr, passphrase)
 }
 
 func Sign(name, passphrase string, msg []byte) (string, error) {
-	sig, pub, err := KB.Sign(name, passphrase, msg)
-	if err != nil {
-		return "", err
-	}
-	stdSign := auth.StdSignature{pub, sig}
-	out, err := gCdc.MarshalJSON(stdSign)
-	if err != nil {
-		return "", err
-	}
-	return string(out), nil
+	return KB.Sign(name, passphrase, msg)
 }
 
-
 // ================================================
 var gCdc = codec.New()
 
@@ -393,26 +1004,26 @@ func init() {
 }
 
 // ================================================
-// AesGcmEncrypt takes an encryption key and a plaintext string and encrypts it with AES256 in GCM mode, 
+// AesGcmEncrypt takes an encryption key and a plaintext string and encrypts it with AES256 in GCM mode,
 // which provides authenticated encryption. Returns the ciphertext and the used nonce.
 // len(key) must be 32, to select AES256
-func AesGcmEncrypt(key []byte, plaintext string) (ciphertext, nonce []byte) {
+func AesGcmEncrypt(key []byte, plaintext string) (ciphertext, nonce []byte, err error) {
 	plaintextBytes := []byte(plaintext)
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		panic(err.Error())
+		return
 	}
 
 	// Never use more than 2^32 random nonces with a given key because of the risk of a repeat.
 	nonce = make([]byte, AesNonceLength)
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		panic(err.Error())
+	if _, err = io.ReadFull(SystemRandom, nonce); err != nil {
+		return
 	}
 
 	aesgcm, err := cipher.NewGCM(block)
 	if err != nil {
-		panic(err.Error())
+		return
 	}
 
 	ciphertext = aesgcm.Seal(nil, nonce, plaintextBytes, nil)
@@ -422,23 +1033,29 @@ func AesGcmEncrypt(key []byte, plaintext string) (ciphertext, nonce []byte) {
 	return
 }
 
-// AesGcmDecrypt takes an decryption key, a ciphertext and the corresponding nonce, 
+// AesGcmDecrypt takes an decryption key, a ciphertext and the corresponding nonce,
 // and decrypts it with AES256 in GCM mode. Returns the plaintext string.
-// len(key) must be 32, to select AES256
-func AesGcmDecrypt(key, ciphertext, nonce []byte) (plaintext string) {
+// len(key) must be 32, to select AES256. ErrDecryptFailed is returned if the key is wrong
+// or the ciphertext was modified.
+func AesGcmDecrypt(key, ciphertext, nonce []byte) (plaintext string, err error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		panic(err.Error())
+		return
 	}
 
 	aesgcm, err := cipher.NewGCM(block)
 	if err != nil {
-		panic(err.Error())
+		return
+	}
+	if len(nonce) != aesgcm.NonceSize() {
+		err = ErrDecryptFailed
+		return
 	}
 
 	plaintextBytes, err := aesgcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		panic(err.Error())
+		err = ErrDecryptFailed
+		return
 	}
 
 	plaintext = string(plaintextBytes)
@@ -0,0 +1,134 @@
+package keykeeper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testMnemonic is the first test vector of BIP39, whose address with DefaultHDPath is testAddress
+const (
+	testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art"
+	testAddress  = "coinex1npxs0zglr29kwtpp998uymwlpymalnq3n6q64u"
+)
+
+// realKdfParams keeps the default KDF parameters, which the benchmarks use for the keybase passphrase
+var realKdfParams = DefaultKdfParams
+
+func TestMain(m *testing.M) {
+	// the default scrypt parameters cost about 0.1 second for each passphrase, which the tests do not need
+	DefaultKdfParams.N = 1 << 10
+	os.Exit(m.Run())
+}
+
+func openTestKeybase(t *testing.T, location string) Keybase {
+	kb, err := Open(location, OpenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return kb
+}
+
+// legacyAccount is an account of testMnemonic as written by the early releases, whose key is the SHA-256 of
+// the passphrase and whose checksum is the SHA-256 of the key
+func legacyAccount(t *testing.T, passphrase string) AccountInfo {
+	sum1 := sha256.Sum256([]byte(passphrase))
+	sum2 := sha256.Sum256(sum1[:])
+	ciphertext, nonce, err := AesGcmEncrypt(sum1[:], testMnemonic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return AccountInfo{
+		Memo:              "legacy",
+		Address:           testAddress,
+		PassphraseCksum:   sum2[:],
+		EncryptedMnemonic: append(nonce, ciphertext...),
+	}
+}
+
+func TestLegacyUpgrade(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "legacy")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	kb := openTestKeybase(t, location)
+	legacy := legacyAccount(t, "p")
+	if err := kb.(*MyKeyBase).AddAccount(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.Sign(testAddress, "bad", []byte("x")); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatal(err)
+	}
+	if acc, _ := kb.GetAccountInfo(testAddress); !acc.Kdf.IsLegacy() {
+		t.Fatal("upgraded with a bad passphrase")
+	}
+	if _, err := kb.Sign(testAddress, "p", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	kb.Close()
+
+	// the upgrade is saved
+	kb = openTestKeybase(t, location)
+	defer kb.Close()
+	acc, _ := kb.GetAccountInfo(testAddress)
+	if acc.Kdf.IsLegacy() || acc.Kdf.Algo != KdfScrypt || bytes.Equal(acc.PassphraseCksum, legacy.PassphraseCksum) || acc.Memo != "legacy" {
+		t.Fatalf("%+v", acc)
+	}
+	if err := acc.CheckPassphrase("bad"); !errors.Is(err, ErrBadPassphrase) {
+		t.Fatal(err)
+	}
+	if mnemonic, err := kb.GetMnemonic(testAddress, "p"); err != nil || mnemonic != testMnemonic {
+		t.Fatal(err, mnemonic)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "readonly")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	if _, err := Open(location, OpenOptions{Mode: OpenReadOnly}); !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	kb := openTestKeybase(t, location)
+	if _, err := kb.CreateAccount("memo", testMnemonic, "p"); err != nil {
+		t.Fatal(err)
+	}
+	kb.Close()
+	content, _ := ioutil.ReadFile(location)
+
+	kb, err := Open(location, OpenOptions{Mode: OpenReadOnly})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kb.Close()
+	if !kb.IsReadOnly() {
+		t.Fatal("not read-only")
+	}
+	if err := kb.Save(); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if _, err := kb.CreateAccountWithPath("memo", testMnemonic, "p", HDPath{CoinType: DefaultCoinType, Index: 1}); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if err := kb.ChangePassphrase(testAddress, "p", "q"); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if err := kb.DeleteAccount(testAddress, "p"); !errors.Is(err, ErrReadOnly) {
+		t.Fatal(err)
+	}
+	if _, err := kb.Sign(testAddress, "p", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if acc, ok := kb.GetAccountInfo(testAddress); !ok || acc.CheckPassphrase("p") != nil {
+		t.Fatal("the account is changed")
+	}
+	if saved, _ := ioutil.ReadFile(location); !bytes.Equal(saved, content) {
+		t.Fatal("the keybase is written")
+	}
+}
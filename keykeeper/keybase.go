@@ -0,0 +1,80 @@
+package keykeeper
+
+// Keybase is a keybase file opened by this package. Each instance has its own accounts, cached passphrases
+// and file lock, so several keybases can be opened at once. All the methods which change the keybase save it.
+type Keybase interface {
+	Open(location string, opts OpenOptions) error
+	OpenStorage(storage Storage, opts OpenOptions) error
+	Close()
+	IsOpen() bool
+	IsReadOnly() bool
+	Storage() Storage
+	Header() KeybaseHeader
+	Save() error
+
+	GetStringItems() []string
+	GetAccountInfo(addr string) (AccountInfo, bool)
+	HasAccount(addr string) bool
+	CreateAccount(memo, mnemonic, passphrase string) (AccountInfo, error)
+	CreateAccountWithPath(memo, mnemonic, passphrase string, path HDPath) (AccountInfo, error)
+	CreateAccountWithKey(memo string, key KeyMaterial, passphrase string) (AccountInfo, error)
+	ImportMnemonic(memo string, key MnemonicKey, passphrase string) (AccountInfo, error)
+	ImportKey(memo string, key KeyMaterial, passphrase string) (AccountInfo, error)
+	ImportPrivKeyHex(memo, hexKey, passphrase string) (AccountInfo, error)
+	ImportArmoredPrivKey(memo, armor, armorPassphrase, passphrase string) (AccountInfo, error)
+	ExportPrivKeyHex(addr, passphrase string) (string, error)
+	ImportKeystore(memo string, keystore []byte, keystorePassphrase, passphrase string) (AccountInfo, error)
+	ImportFromKeyring(kr *Keyring, names []string, passphraseOf func(name string) (string, error),
+		passphrase string) ([]AccountInfo, error)
+	ExportArmoredPrivKey(addr, passphrase, exportPassphrase string) (string, error)
+	ExportKeystore(addr, passphrase, keystorePassphrase string) ([]byte, error)
+	ExportArmoredPrivKeys(dir string, addrs []string, passphraseOf func(addr string) (string, error),
+		exportPassphrase string) ([]string, error)
+	ChangePassphrase(addr, oldPassphrase, newPassphrase string) error
+	DeleteAccount(addr, passphrase string) error
+	GetMnemonic(addr, passphrase string) (string, error)
+	GetMnemonicKey(addr, passphrase string) (MnemonicKey, error)
+	GetCachedPassphrase(addr string) (string, bool)
+	AddCachedPassphrase(addr, passphrase string) error
+	Sign(addr, passphrase string, msg []byte) (string, error)
+
+	CreateWallet(memo string, key MnemonicKey, passphrase string) (WalletInfo, error)
+	GetWallet(id string) (WalletInfo, bool)
+	ListWallets() []WalletInfo
+	ListWalletChildren(id string) []AccountInfo
+	AddWalletChild(id, passphrase, memo string) (AccountInfo, error)
+	ChangeWalletPassphrase(id, oldPassphrase, newPassphrase string) error
+	DeleteWallet(id, passphrase string) error
+
+	IsSealed() bool
+	HasKeybasePassphrase() bool
+	SetKeybasePassphrase(oldPassphrase, newPassphrase string) error
+	Seal(passphrase string) error
+	Unseal(passphrase string) error
+
+	SetBackupGenerations(n int)
+	ListBackups() ([]BackupInfo, error)
+	RestoreBackup(backupPath string, prompt PassphrasePrompt) error
+}
+
+var _ Keybase = (*MyKeyBase)(nil)
+
+// NewKeybase returns an instance which is not opened yet
+func NewKeybase() Keybase {
+	return &MyKeyBase{}
+}
+
+// Open returns a new instance with the keybase at location opened, see NewStorage for the locations
+func Open(location string, opts OpenOptions) (Keybase, error) {
+	kb := &MyKeyBase{}
+	err := kb.Open(location, opts)
+	if err != nil {
+		return nil, err
+	}
+	return kb, nil
+}
+
+// DefaultKeybase returns KB, the instance used by the package-level functions
+func DefaultKeybase() Keybase {
+	return &KB
+}
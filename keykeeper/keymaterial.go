@@ -0,0 +1,277 @@
+package keykeeper
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/mintkey"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+const (
+	// KeyTypeMnemonic accounts hold a BIP39 mnemonic, from which the key is derived with their HD path.
+	// The accounts created before the key types were added have an empty KeyType, which means this type.
+	KeyTypeMnemonic = "mnemonic"
+	// KeyTypeSecp256k1 accounts hold a raw secp256k1 private key
+	KeyTypeSecp256k1 = "secp256k1"
+)
+
+// KeyMaterial is the secret held by an account, which is encrypted with the account's passphrase
+type KeyMaterial interface {
+	KeyType() string
+	// PrivKey returns the private key used to sign
+	PrivKey() (secp256k1.PrivKeySecp256k1, error)
+}
+
+// MnemonicKey derives the private key from a BIP39 mnemonic with an HD path
+type MnemonicKey struct {
+	Mnemonic string
+	Path     HDPath
+	// BIP39Passphrase is the optional passphrase mixed into the seed, which is also known as the 25th word.
+	// The same mnemonic gets a totally different key with a different BIP39 passphrase.
+	BIP39Passphrase string
+	// Language is the language of the mnemonic's wordlist, and the empty one stands for English
+	Language string
+}
+
+func (k MnemonicKey) KeyType() string {
+	return KeyTypeMnemonic
+}
+
+func (k MnemonicKey) PrivKey() (secp256k1.PrivKeySecp256k1, error) {
+	privk, _, _, err := getAllFromMnemonic(k)
+	return privk, err
+}
+
+// validateKey checks a new mnemonic with the wordlist of its language. The stored mnemonics are not checked again,
+// so their accounts can still sign and export when the wordlist is not loaded.
+func validateKey(key KeyMaterial) error {
+	k, ok := key.(MnemonicKey)
+	if !ok {
+		return nil
+	}
+	wl, err := GetWordlist(k.Language)
+	if err != nil {
+		return err
+	}
+	return wl.Validate(k.Mnemonic)
+}
+
+// storedLanguage keeps English out of the stored accounts and wallets, as they were before the other languages
+func (k MnemonicKey) storedLanguage() string {
+	if k.Language == LanguageEnglish {
+		return ""
+	}
+	return k.Language
+}
+
+// RawKey is a secp256k1 private key imported as it is, e.g. from hex or from the armor of `cetcli keys export`
+type RawKey secp256k1.PrivKeySecp256k1
+
+func (k RawKey) KeyType() string {
+	return KeyTypeSecp256k1
+}
+
+func (k RawKey) PrivKey() (secp256k1.PrivKeySecp256k1, error) {
+	return secp256k1.PrivKeySecp256k1(k), nil
+}
+
+// secp256k1N is the order of the secp256k1 group, a private key must be in [1, N-1]
+var secp256k1N, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// ParseRawKeyHex parses a 32-byte private key in hex, which can be prefixed with "0x"
+func ParseRawKeyHex(s string) (k RawKey, err error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	bz, err := hex.DecodeString(s)
+	if err != nil || len(bz) != len(k) {
+		return k, fmt.Errorf("%w: it must be 32 bytes in hex", ErrInvalidPrivKey)
+	}
+	n := new(big.Int).SetBytes(bz)
+	if n.Sign() == 0 || n.Cmp(secp256k1N) >= 0 {
+		return k, fmt.Errorf("%w: it is out of the range of secp256k1", ErrInvalidPrivKey)
+	}
+	copy(k[:], bz)
+	return k, nil
+}
+
+// ParseArmoredRawKey decrypts a private key armored by `cetcli keys export` or by cosmos-sdk's mintkey.
+// It returns ErrBadPassphrase if armorPassphrase is wrong.
+func ParseArmoredRawKey(armor, armorPassphrase string) (RawKey, error) {
+	privKey, err := mintkey.UnarmorDecryptPrivKey(armor, armorPassphrase)
+	if keyerror.IsErrWrongPassword(err) {
+		return RawKey{}, ErrBadPassphrase
+	}
+	if err != nil {
+		return RawKey{}, fmt.Errorf("%w: %v", ErrInvalidPrivKey, err)
+	}
+	privk, ok := privKey.(secp256k1.PrivKeySecp256k1)
+	if !ok {
+		return RawKey{}, fmt.Errorf("%w: %T is not a secp256k1 key", ErrInvalidPrivKey, privKey)
+	}
+	return RawKey(privk), nil
+}
+
+func addressOfKey(key KeyMaterial) (string, error) {
+	privk, err := key.PrivKey()
+	if err != nil {
+		return "", err
+	}
+	return sdk.AccAddress(privk.PubKey().Address()).String(), nil
+}
+
+// NewAccountInfoWithKey encrypts key with passphrase, using the KDF parameters of kdfTmpl with a fresh salt
+func NewAccountInfoWithKey(memo string, key KeyMaterial, passphrase string, kdfTmpl KdfParams) (acc AccountInfo, err error) {
+	acc.Memo = memo
+	acc.Address, err = addressOfKey(key)
+	if err != nil {
+		return
+	}
+	var secrets []string
+	switch k := key.(type) {
+	case MnemonicKey:
+		secrets = []string{k.Mnemonic, k.BIP39Passphrase}
+		acc.HDPath = &k.Path
+		acc.Language = k.storedLanguage()
+	case RawKey:
+		secrets = []string{hex.EncodeToString(k[:])}
+		acc.KeyType = KeyTypeSecp256k1
+	default:
+		return acc, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, key.KeyType())
+	}
+	kdf, cksum, ciphertexts, err := encryptSecrets(passphrase, kdfTmpl, secrets...)
+	if err != nil {
+		return
+	}
+	acc.Kdf, acc.PassphraseCksum = kdf, cksum
+	if acc.KeyType == KeyTypeSecp256k1 {
+		acc.EncryptedPrivKey = ciphertexts[0]
+	} else {
+		acc.EncryptedMnemonic, acc.EncryptedBIP39Passphrase = ciphertexts[0], ciphertexts[1]
+	}
+	return
+}
+
+// GetKeyType returns the type of the key material held by the account
+func (acc AccountInfo) GetKeyType() string {
+	if acc.KeyType == "" {
+		return KeyTypeMnemonic
+	}
+	return acc.KeyType
+}
+
+// decryptKey decrypts the key material of an account which is not a child of a wallet
+func (acc AccountInfo) decryptKey(passphrase string) (KeyMaterial, error) {
+	switch acc.GetKeyType() {
+	case KeyTypeMnemonic:
+		return acc.decryptMnemonicKey(passphrase)
+	case KeyTypeSecp256k1:
+		secret, err := decryptSecret(acc.Kdf, acc.PassphraseCksum, acc.EncryptedPrivKey, passphrase)
+		if err != nil {
+			return nil, accountError(acc.Address, err)
+		}
+		k, err := ParseRawKeyHex(secret)
+		if err != nil {
+			return nil, accountError(acc.Address, ErrCorruptedAccount)
+		}
+		return k, nil
+	}
+	return nil, accountError(acc.Address, fmt.Errorf("%w: %s", ErrUnsupportedKeyType, acc.KeyType))
+}
+
+// keyOf decrypts the key material of an account. A child of a wallet gets the wallet's mnemonic with its own path.
+func (kb *MyKeyBase) keyOf(acc AccountInfo, passphrase string) (KeyMaterial, error) {
+	if acc.Wallet == "" {
+		return acc.decryptKey(passphrase)
+	}
+	return kb.mnemonicKeyOf(acc, passphrase)
+}
+
+// ImportKey adds an account holding key, and saves the keybase. The account must not be in the keybase yet.
+func (kb *MyKeyBase) ImportKey(memo string, key KeyMaterial, passphrase string) (AccountInfo, error) {
+	err := validateKey(key)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	addr, err := addressOfKey(key)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	if kb.HasAccount(addr) {
+		return AccountInfo{}, accountError(addr, ErrAccountExists)
+	}
+	acc, err := NewAccountInfoWithKey(memo, key, passphrase, kb.Header().Kdf)
+	if err != nil {
+		return acc, err
+	}
+	err = kb.AddAccount(acc)
+	if err != nil {
+		return acc, err
+	}
+	return acc, kb.Save()
+}
+
+// ImportPrivKeyHex adds an account holding a raw private key in hex, and saves the keybase
+func (kb *MyKeyBase) ImportPrivKeyHex(memo, hexKey, passphrase string) (AccountInfo, error) {
+	key, err := ParseRawKeyHex(hexKey)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	return kb.ImportKey(memo, key, passphrase)
+}
+
+// ImportArmoredPrivKey adds an account holding the private key in an armor of `cetcli keys export`,
+// which is decrypted with armorPassphrase and then encrypted with passphrase in the keybase
+func (kb *MyKeyBase) ImportArmoredPrivKey(memo, armor, armorPassphrase, passphrase string) (AccountInfo, error) {
+	key, err := ParseArmoredRawKey(armor, armorPassphrase)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	return kb.ImportKey(memo, key, passphrase)
+}
+
+// ExportPrivKeyHex returns the private key of any account in hex
+func (kb *MyKeyBase) ExportPrivKeyHex(addr, passphrase string) (string, error) {
+	privk, err := kb.privKeyOf(addr, passphrase)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(privk[:]), nil
+}
+
+// privKeyOf returns the private key of an account, after checking that it matches the address
+func (kb *MyKeyBase) privKeyOf(addr, passphrase string) (privk secp256k1.PrivKeySecp256k1, err error) {
+	accInfo, ok := kb.GetAccountInfo(addr)
+	if !ok {
+		err = accountError(addr, ErrNoSuchAccount)
+		return
+	}
+	key, err := kb.keyOf(accInfo, passphrase)
+	if err != nil {
+		return
+	}
+	privk, err = key.PrivKey()
+	if err != nil {
+		return
+	}
+	if derivedAddr, _ := addressOfKey(RawKey(privk)); derivedAddr != addr {
+		// the recorded path or key has been changed, so the key would not match the address
+		err = accountError(addr, ErrCorruptedAccount)
+	}
+	return
+}
+
+func ImportPrivKeyHex(memo, hexKey, passphrase string) (AccountInfo, error) {
+	return KB.ImportPrivKeyHex(memo, hexKey, passphrase)
+}
+
+func ImportArmoredPrivKey(memo, armor, armorPassphrase, passphrase string) (AccountInfo, error) {
+	return KB.ImportArmoredPrivKey(memo, armor, armorPassphrase, passphrase)
+}
+
+func ExportPrivKeyHex(addr, passphrase string) (string, error) {
+	return KB.ExportPrivKeyHex(addr, passphrase)
+}
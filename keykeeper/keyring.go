@@ -0,0 +1,132 @@
+package keykeeper
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cosmos/cosmos-sdk/crypto/keys"
+	"github.com/cosmos/cosmos-sdk/crypto/keys/keyerror"
+	"github.com/tendermint/tendermint/crypto/secp256k1"
+)
+
+// the keyring of cetcli is the LevelDB "<home>/keys/keys.db", as opened by cosmos-sdk's client/keys
+const (
+	keyringDir    = "keys"
+	keyringDBName = "keys"
+)
+
+// KeyringKey is a key listed in a cetcli keyring
+type KeyringKey struct {
+	Name    string
+	Address string
+	// Type is "local", "ledger", "offline" or "multi", and only the local keys hold their private keys
+	Type string
+}
+
+// Importable returns true if the private key is held in the keyring
+func (k KeyringKey) Importable() bool {
+	return k.Type == keys.TypeLocal.String()
+}
+
+// Keyring is the keyring in the home directory of cetcli, e.g. "~/.cetcli"
+type Keyring struct {
+	home string
+	kb   keys.Keybase
+}
+
+// OpenKeyring opens the keyring in a home directory of cetcli. It is not changed by this package.
+func OpenKeyring(home string) (*Keyring, error) {
+	dir := filepath.Join(home, keyringDir)
+	// keys.New creates a keyring when it does not exist, so check it before
+	fi, err := os.Stat(filepath.Join(dir, keyringDBName+".db"))
+	if err == nil && !fi.IsDir() {
+		err = ErrNotKeyring
+	}
+	if os.IsNotExist(err) {
+		err = ErrNotKeyring
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: home, Err: err}
+	}
+	return &Keyring{home: home, kb: keys.New(keyringDBName, dir)}, nil
+}
+
+func (kr *Keyring) String() string {
+	return kr.home
+}
+
+func (kr *Keyring) List() ([]KeyringKey, error) {
+	infos, err := kr.kb.List()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]KeyringKey, len(infos))
+	for i, info := range infos {
+		res[i] = KeyringKey{Name: info.GetName(), Address: info.GetAddress().String(), Type: info.GetType().String()}
+	}
+	return res, nil
+}
+
+// Export decrypts the private key of a local key with its keyring passphrase
+func (kr *Keyring) Export(name, passphrase string) (RawKey, error) {
+	info, err := kr.kb.Get(name)
+	if err != nil {
+		return RawKey{}, err
+	}
+	if info.GetType() != keys.TypeLocal {
+		return RawKey{}, fmt.Errorf("%w: %s is a %s key", ErrNotLocalKey, name, info.GetType())
+	}
+	privKey, err := kr.kb.ExportPrivateKeyObject(name, passphrase)
+	if keyerror.IsErrWrongPassword(err) {
+		return RawKey{}, accountError(info.GetAddress().String(), ErrBadPassphrase)
+	}
+	if err != nil {
+		return RawKey{}, err
+	}
+	privk, ok := privKey.(secp256k1.PrivKeySecp256k1)
+	if !ok {
+		return RawKey{}, fmt.Errorf("%w: %T is not a secp256k1 key", ErrInvalidPrivKey, privKey)
+	}
+	return RawKey(privk), nil
+}
+
+// ImportFromKeyring imports the keys named in names from kr as raw-key accounts whose memos are the names.
+// passphraseOf is called to get the keyring passphrase of each key, and all the accounts are encrypted with
+// passphrase. Either all the keys are imported with one Save, or none is imported when any of them fails.
+func (kb *MyKeyBase) ImportFromKeyring(kr *Keyring, names []string, passphraseOf func(name string) (string, error),
+	passphrase string) ([]AccountInfo, error) {
+	accounts := make([]AccountInfo, len(names))
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		keyringPass, err := passphraseOf(name)
+		if err != nil {
+			return nil, err
+		}
+		key, err := kr.Export(name, keyringPass)
+		if err != nil {
+			return nil, err
+		}
+		accounts[i], err = NewAccountInfoWithKey(name, key, passphrase, kb.Header().Kdf)
+		if err != nil {
+			return nil, err
+		}
+		addr := accounts[i].Address
+		if seen[addr] || kb.HasAccount(addr) {
+			return nil, accountError(addr, ErrAccountExists)
+		}
+		seen[addr] = true
+	}
+	for _, acc := range accounts {
+		err := kb.AddAccount(acc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accounts, kb.Save()
+}
+
+func ImportFromKeyring(kr *Keyring, names []string, passphraseOf func(name string) (string, error),
+	passphrase string) ([]AccountInfo, error) {
+	return KB.ImportFromKeyring(kr, names, passphraseOf, passphrase)
+}
@@ -0,0 +1,220 @@
+package keykeeper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// KeystoreVersion is the version of Web3 Secret Storage read and written by this package
+const KeystoreVersion = 3
+
+// Keystore is the JSON of Web3 Secret Storage version 3. Its Address is the coinex bech32 address
+// instead of an Ethereum address, and it is checked against the key when it is imported.
+type Keystore struct {
+	Version int            `json:"version"`
+	ID      string         `json:"id"`
+	Address string         `json:"address"`
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string `json:"cipher"`
+	CipherText   string `json:"ciphertext"`
+	CipherParams struct {
+		IV string `json:"iv"`
+	} `json:"cipherparams"`
+	Kdf       string                 `json:"kdf"`
+	KdfParams map[string]interface{} `json:"kdfparams"`
+	Mac       string                 `json:"mac"`
+}
+
+const (
+	keystoreCipher = "aes-128-ctr"
+	keystoreDkLen  = 32
+)
+
+// EncryptKeystore encrypts key into the JSON of Web3 Secret Storage, with scrypt using the tunable parameters of kdfTmpl
+func EncryptKeystore(key RawKey, passphrase string, kdfTmpl KdfParams) ([]byte, error) {
+	kdf, err := NewKdfParams(kdfTmpl)
+	if err != nil {
+		return nil, err
+	}
+	dk, err := scrypt.Key([]byte(passphrase), kdf.Salt, kdf.N, kdf.R, kdf.P, keystoreDkLen)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	uuid := make([]byte, 16)
+	for _, b := range [][]byte{iv, uuid} {
+		if _, err := io.ReadFull(SystemRandom, b); err != nil {
+			return nil, err
+		}
+	}
+	ciphertext, err := aesCtr(dk[:16], iv, key[:])
+	if err != nil {
+		return nil, err
+	}
+	addr, err := addressOfKey(key)
+	if err != nil {
+		return nil, err
+	}
+	// a random UUID of version 4
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	ks := Keystore{
+		Version: KeystoreVersion,
+		ID:      fmt.Sprintf("%x-%x-%x-%x-%x", uuid[:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]),
+		Address: addr,
+	}
+	ks.Crypto.Cipher = keystoreCipher
+	ks.Crypto.CipherText = hex.EncodeToString(ciphertext)
+	ks.Crypto.CipherParams.IV = hex.EncodeToString(iv)
+	ks.Crypto.Kdf = KdfScrypt
+	ks.Crypto.KdfParams = map[string]interface{}{
+		"dklen": keystoreDkLen,
+		"salt":  hex.EncodeToString(kdf.Salt),
+		"n":     kdf.N,
+		"r":     kdf.R,
+		"p":     kdf.P,
+	}
+	ks.Crypto.Mac = hex.EncodeToString(keystoreMac(dk, ciphertext))
+	return json.MarshalIndent(ks, "", "  ")
+}
+
+// DecryptKeystore decrypts the key in the JSON of Web3 Secret Storage, whose KDF can be scrypt or pbkdf2.
+// It returns ErrBadPassphrase when the MAC does not match.
+func DecryptKeystore(bz []byte, passphrase string) (key RawKey, err error) {
+	var ks Keystore
+	err = json.Unmarshal(bz, &ks)
+	if err != nil {
+		return key, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+	}
+	if ks.Version != KeystoreVersion {
+		return key, fmt.Errorf("%w: version %d is not supported", ErrInvalidKeystore, ks.Version)
+	}
+	if ks.Crypto.Cipher != keystoreCipher {
+		return key, fmt.Errorf("%w: cipher %s is not supported", ErrInvalidKeystore, ks.Crypto.Cipher)
+	}
+	ciphertext, err1 := hex.DecodeString(ks.Crypto.CipherText)
+	iv, err2 := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	mac, err3 := hex.DecodeString(ks.Crypto.Mac)
+	if err1 != nil || err2 != nil || err3 != nil || len(iv) != aes.BlockSize {
+		return key, fmt.Errorf("%w: bad hex in crypto", ErrInvalidKeystore)
+	}
+	dk, err := ks.Crypto.deriveKey(passphrase)
+	if err != nil {
+		return
+	}
+	if !hmac.Equal(keystoreMac(dk, ciphertext), mac) {
+		return key, ErrBadPassphrase
+	}
+	plaintext, err := aesCtr(dk[:16], iv, ciphertext)
+	if err != nil {
+		return
+	}
+	key, err = ParseRawKeyHex(hex.EncodeToString(plaintext))
+	if err != nil {
+		return
+	}
+	addr, err := addressOfKey(key)
+	if err != nil {
+		return
+	}
+	// the keystores from other tools may hold an Ethereum address, which can not be compared
+	if strings.HasPrefix(ks.Address, AddrPrefix) && ks.Address != addr {
+		return RawKey{}, fmt.Errorf("%w: the key does not match the address %s", ErrInvalidKeystore, ks.Address)
+	}
+	return key, nil
+}
+
+func (c keystoreCrypto) deriveKey(passphrase string) ([]byte, error) {
+	intParam := func(name string) int {
+		// JSON numbers are decoded as float64, and the huge ones are taken as invalid
+		f, _ := c.KdfParams[name].(float64)
+		if f < 0 || f > math.MaxInt32 {
+			return -1
+		}
+		return int(f)
+	}
+	salt, err := hex.DecodeString(fmt.Sprint(c.KdfParams["salt"]))
+	dkLen := intParam("dklen")
+	if err != nil || dkLen != keystoreDkLen {
+		return nil, fmt.Errorf("%w: bad kdfparams", ErrInvalidKeystore)
+	}
+	switch c.Kdf {
+	case KdfScrypt:
+		if err := checkScryptParams(intParam("n"), intParam("r"), intParam("p")); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		dk, err := scrypt.Key([]byte(passphrase), salt, intParam("n"), intParam("r"), intParam("p"), dkLen)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeystore, err)
+		}
+		return dk, nil
+	case "pbkdf2":
+		if c.KdfParams["prf"] != "hmac-sha256" || intParam("c") <= 0 {
+			return nil, fmt.Errorf("%w: bad kdfparams", ErrInvalidKeystore)
+		}
+		if intParam("c") > MaxPbkdf2Iterations {
+			return nil, fmt.Errorf("%w: pbkdf2 iterations c=%d are over the limit", ErrInvalidKeystore, intParam("c"))
+		}
+		return pbkdf2.Key([]byte(passphrase), salt, intParam("c"), dkLen, sha256.New), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedKdf, c.Kdf)
+}
+
+func keystoreMac(dk, ciphertext []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(dk[16:32])
+	h.Write(ciphertext)
+	return h.Sum(nil)
+}
+
+func aesCtr(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// ExportKeystore returns the private key of any account in the JSON of Web3 Secret Storage,
+// encrypted with keystorePassphrase by the keybase's default KDF parameters
+func (kb *MyKeyBase) ExportKeystore(addr, passphrase, keystorePassphrase string) ([]byte, error) {
+	privk, err := kb.privKeyOf(addr, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptKeystore(RawKey(privk), keystorePassphrase, kb.Header().Kdf)
+}
+
+// ImportKeystore adds an account holding the raw key in the JSON of Web3 Secret Storage, and saves the keybase
+func (kb *MyKeyBase) ImportKeystore(memo string, keystore []byte, keystorePassphrase, passphrase string) (AccountInfo, error) {
+	key, err := DecryptKeystore(keystore, keystorePassphrase)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	return kb.ImportKey(memo, key, passphrase)
+}
+
+func ExportKeystore(addr, passphrase, keystorePassphrase string) ([]byte, error) {
+	return KB.ExportKeystore(addr, passphrase, keystorePassphrase)
+}
+
+func ImportKeystore(memo string, keystore []byte, keystorePassphrase, passphrase string) (AccountInfo, error) {
+	return KB.ImportKeystore(memo, keystore, keystorePassphrase, passphrase)
+}
@@ -0,0 +1,81 @@
+package keykeeper
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the test vectors of Web3 Secret Storage, whose key is testKeystoreKey with the passphrase "testpassword"
+var testKeystores = []string{
+	`{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"83dbcc02d8ccb40e466191a123791e0e"},"ciphertext":"d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c","kdf":"scrypt","kdfparams":{"dklen":32,"n":262144,"r":1,"p":8,"salt":"ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},"mac":"2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+	`{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`,
+}
+
+const testKeystoreKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+func TestDecryptKeystore(t *testing.T) {
+	for _, ks := range testKeystores {
+		key, err := DecryptKeystore([]byte(ks), "testpassword")
+		if err != nil || hex.EncodeToString(key[:]) != testKeystoreKey {
+			t.Fatal(err)
+		}
+		if _, err := DecryptKeystore([]byte(ks), "x"); !errors.Is(err, ErrBadPassphrase) {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKeystoreKdfLimits(t *testing.T) {
+	scryptKs, pbkdf2Ks := testKeystores[0], testKeystores[1]
+	for _, ks := range []string{
+		strings.Replace(scryptKs, `"n":262144`, `"n":2097152`, 1),
+		strings.Replace(scryptKs, `"r":1`, `"r":32`, 1),
+		strings.Replace(scryptKs, `"p":8`, `"p":64`, 1),
+		strings.Replace(scryptKs, `"n":262144,"r":1`, `"n":1048576,"r":16`, 1),
+		strings.Replace(scryptKs, `"n":262144`, `"n":1e300`, 1),
+		strings.Replace(pbkdf2Ks, `"c":262144`, `"c":100000000`, 1),
+	} {
+		start := time.Now()
+		if _, err := DecryptKeystore([]byte(ks), "testpassword"); !errors.Is(err, ErrInvalidKeystore) {
+			t.Fatal(err)
+		}
+		if time.Since(start) > time.Second {
+			t.Fatal("the parameters are not checked before the derivation")
+		}
+	}
+}
+
+func TestKeystoreRoundTrip(t *testing.T) {
+	kb := openTestKeybase(t, "mem://")
+	defer kb.Close()
+	acc, _ := kb.CreateAccount("a", testMnemonic, "p")
+	ks, err := kb.ExportKeystore(acc.Address, "p", "kp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kb2 := openTestKeybase(t, "mem://")
+	defer kb2.Close()
+	acc2, err := kb2.ImportKeystore("b", ks, "kp", "q")
+	if err != nil || acc2.Address != acc.Address {
+		t.Fatal(err)
+	}
+	bad := strings.Replace(string(ks), acc.Address, "coinex1k6udhetfl43r2fnj3zk8nk9yxtwczwwse3h9jq", 1)
+	if _, err := DecryptKeystore([]byte(bad), "kp"); !errors.Is(err, ErrInvalidKeystore) {
+		t.Fatal(err)
+	}
+}
+
+func TestKeybaseKdfLimits(t *testing.T) {
+	for _, p := range []KdfParams{
+		{Algo: KdfScrypt, N: 1 << 21, R: 8, P: 1},
+		{Algo: KdfScrypt, N: 1 << 10, R: 17, P: 1},
+		{Algo: KdfScrypt, N: 1 << 10, R: 8, P: 17},
+	} {
+		if _, _, err := p.deriveKeys("p"); !errors.Is(err, ErrUnsupportedKdf) {
+			t.Fatal(p, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package keykeeper
+
+import (
+	"os"
+)
+
+// fileLock is an exclusive advisory lock held on "<keybase>.lock" while a keybase is open.
+// The keybase itself can not be locked, because each Save replaces it with a new file.
+// The lock file is left on disk when unlocked, since removing it would race with another process locking it.
+type fileLock struct {
+	f *os.File
+}
+
+func lockFileName(fname string) string {
+	return fname + ".lock"
+}
+
+// lockKeybase returns ErrKeybaseLocked if another process, or another open in this process, holds the lock
+func lockKeybase(fname string) (*fileLock, error) {
+	f, err := os.OpenFile(lockFileName(fname), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = tryLockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileLock{f: f}, nil
+}
+
+// unlock is a no-op on a nil lock
+func (l *fileLock) unlock() error {
+	if l == nil {
+		return nil
+	}
+	err := unlockFile(l.f)
+	if closeErr := l.f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
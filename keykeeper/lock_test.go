@@ -0,0 +1,78 @@
+package keykeeper
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenLocked(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lock")
+	defer os.RemoveAll(dir)
+	for _, location := range []string{filepath.Join(dir, "kb.json"), "dir://" + filepath.Join(dir, "kb"), "mem://locked"} {
+		kb := openTestKeybase(t, location)
+		if _, err := Open(location, OpenOptions{}); !errors.Is(err, ErrKeybaseLocked) {
+			t.Fatal(location, err)
+		}
+		// a read-only open does not need the lock
+		ro, err := Open(location, OpenOptions{Mode: OpenReadOnly})
+		if err != nil {
+			t.Fatal(location, err)
+		}
+		ro.Close()
+		kb.Close()
+		kb = openTestKeybase(t, location)
+		kb.Close()
+	}
+}
+
+// TestLockHelperProcess holds a keybase open for TestOpenLockedByProcess, until its stdin is closed
+func TestLockHelperProcess(t *testing.T) {
+	location := os.Getenv("KEYKEEPER_LOCK_HELPER")
+	if location == "" {
+		return
+	}
+	kb, err := Open(location, OpenOptions{})
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	ioutil.ReadAll(os.Stdin)
+	kb.Close()
+	os.Exit(0)
+}
+
+func TestOpenLockedByProcess(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "lock")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLockHelperProcess$")
+	cmd.Env = append(os.Environ(), "KEYKEEPER_LOCK_HELPER="+location)
+	stdin, _ := cmd.StdinPipe()
+	stdout, _ := cmd.StdoutPipe()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if line, _ := bufio.NewReader(stdout).ReadString('\n'); line != "locked\n" {
+		stdin.Close()
+		cmd.Wait()
+		t.Fatal(line)
+	}
+	_, err := Open(location, OpenOptions{})
+	stdin.Close()
+	if waitErr := cmd.Wait(); waitErr != nil {
+		t.Fatal(waitErr)
+	}
+	if !errors.Is(err, ErrKeybaseLocked) {
+		t.Fatal(err)
+	}
+	// the lock is released with the process
+	kb := openTestKeybase(t, location)
+	kb.Close()
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package keykeeper
+
+import (
+	"os"
+	"syscall"
+)
+
+// flock locks are held per open file description, so a second open in the same process also fails
+func tryLockFile(f *os.File) error {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return &os.PathError{Op: "lock", Path: f.Name(), Err: ErrKeybaseLocked}
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
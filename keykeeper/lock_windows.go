@@ -0,0 +1,25 @@
+//go:build windows
+// +build windows
+
+package keykeeper
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// the first byte of the lock file is locked, which is enough since all the lockers agree on it
+func tryLockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	err := windows.LockFileEx(windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return &os.PathError{Op: "lock", Path: f.Name(), Err: ErrKeybaseLocked}
+	}
+	return err
+}
+
+func unlockFile(f *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
@@ -1,13 +1,13 @@
 package keykeeper
 
 import (
+	"encoding/binary"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"golang.org/x/crypto/blake2b"
-
-	bip39 "github.com/cosmos/go-bip39"
 )
 
 const (
@@ -35,81 +35,128 @@ func init() {
 	}
 }
 
+// tryResult is shared by the workers of GenerateMnemonic, and only the first match is kept
 type tryResult struct {
+	mtx      sync.Mutex
 	found    bool
 	addr     string
 	mnemonic string
+	index    uint64
+}
+
+func (r *tryResult) isFound() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.found
+}
+
+// set keeps the match unless another worker has found one, so the index always belongs to the mnemonic
+func (r *tryResult) set(addr, mnemonic string, index uint64) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if !r.found {
+		r.found, r.addr, r.mnemonic, r.index = true, addr, mnemonic, index
+	}
 }
 
-func GenerateMnemonic(prefix, suffix string, repFn func(uint64, float64), numCpu int) (string, string) {
+// GenerateMnemonic searches for a mnemonic of the given number of words in the language of tmpl, whose address
+// derived with the path and the BIP39 passphrase of tmpl has the prefix and the suffix. The mnemonic of tmpl is ignored.
+// The candidates are derived from start by CandidateEntropy, and the index of the found one is returned,
+// so the search can be reproduced from an EntropyMix. A nil start means fresh system randomness.
+// The candidate 0 is tried first, so it is returned when there is no prefix or suffix to search for.
+func GenerateMnemonic(prefix, suffix string, tmpl MnemonicKey, words int, start []byte,
+	repFn func(uint64, float64), numCpu int) (addr, mnemonic string, index uint64, err error) {
+	entropyBits, err := MnemonicEntropyBits(words)
+	if err != nil {
+		return
+	}
+	wl, err := GetWordlist(tmpl.Language)
+	if err != nil {
+		return
+	}
+	if start == nil {
+		start, err = newEntropy(entropyBits)
+		if err != nil {
+			return
+		}
+	} else if len(start)*8 != entropyBits {
+		err = fmt.Errorf("%w: %d bits are needed for %d words", ErrInvalidEntropy, entropyBits, words)
+		return
+	}
+	addr, mnemonic, err = getAddressFromEntropy(start, wl, tmpl)
+	if err != nil || strings.HasPrefix(addr, prefix) && strings.HasSuffix(addr, suffix) {
+		return
+	}
 	var totalTry float64
 	totalTry = 1.0
 	n := len(prefix+suffix) - len("coinex1")
 	for i := 0; i < n; i++ {
 		totalTry *= 32.0
 	}
-	resPtr := &tryResult{}
+	res := &tryResult{}
 	var globalCounter uint64
-	var resAtomic atomic.Value
-	resAtomic.Store(resPtr)
 	var wg sync.WaitGroup
 	wg.Add(numCpu)
 	for i := 0; i < numCpu; i++ {
-		go tryAddress(prefix, suffix, repFn, resAtomic, &wg, &globalCounter, totalTry)
+		go tryAddress(prefix, suffix, wl, tmpl, start, uint64(i+1), uint64(numCpu),
+			repFn, res, &wg, &globalCounter, totalTry)
 	}
 	wg.Wait()
-	return resPtr.addr, resPtr.mnemonic
+	return res.addr, res.mnemonic, res.index, nil
+}
+
+// CandidateEntropy returns the entropy of the index-th candidate searched by GenerateMnemonic. The candidate 0 is
+// start itself, and the others are blake2b-256(start || index as 8 big-endian bytes) truncated to the size of start.
+func CandidateEntropy(start []byte, index uint64) []byte {
+	if index == 0 {
+		return start
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], index)
+	sum := blake2b.Sum256(append(append([]byte{}, start...), buf[:]...))
+	return sum[:len(start)]
 }
 
 const BatchCount = 200
 const BigBatchCount = 10 * BatchCount
 
-func tryAddress(prefix, suffix string, repFn func(uint64, float64),
-	resAtomic atomic.Value, wg *sync.WaitGroup, globalCounter *uint64, totalTry float64) {
+// tryAddress searches the candidates first, first+step, first+2*step...
+func tryAddress(prefix, suffix string, wl *Wordlist, tmpl MnemonicKey, start []byte, first, step uint64,
+	repFn func(uint64, float64), res *tryResult, wg *sync.WaitGroup, globalCounter *uint64, totalTry float64) {
 
-	entropy, err := bip39.NewEntropy(256)
-	if err != nil {
-		panic(err.Error())
-	}
 	counter := 0
-	for {
+	for index := first; ; index += step {
 		if counter%BatchCount == 0 {
-			resPtr := resAtomic.Load().(*tryResult)
-			if resPtr.found {
+			if res.isFound() {
 				break
 			}
 			count := atomic.AddUint64(globalCounter, BatchCount)
-			if count%BigBatchCount == 0 {
+			if count%BigBatchCount == 0 && repFn != nil {
 				percent := 100.0 * float64(count) / totalTry
 				repFn(count, percent)
 			}
 		}
-		addr, mnemonic, err := getAddressFromEntropy(entropy)
+		addr, mnemonic, err := getAddressFromEntropy(CandidateEntropy(start, index), wl, tmpl)
 		if err != nil {
 			panic(err.Error())
 		}
 		if strings.HasPrefix(addr, prefix) && strings.HasSuffix(addr, suffix) {
-			resPtr := resAtomic.Load().(*tryResult)
-			resPtr.found = true
-			resPtr.addr = addr
-			resPtr.mnemonic = mnemonic
-			resAtomic.Store(resPtr)
+			res.set(addr, mnemonic, index)
 			break
 		}
-		sum := blake2b.Sum256(entropy)
-		entropy = sum[:]
 		counter++
 	}
 	wg.Done()
 }
 
-func getAddressFromEntropy(entropy []byte) (string, string, error) {
-	mnemonic, err := bip39.NewMnemonic(entropy)
+func getAddressFromEntropy(entropy []byte, wl *Wordlist, tmpl MnemonicKey) (string, string, error) {
+	mnemonic, err := wl.NewMnemonic(entropy)
 	if err != nil {
 		return "", mnemonic, err
 	}
 
-	_, _, addr := getAllFromMnemonic(mnemonic)
-	return addr, mnemonic, nil
+	tmpl.Mnemonic = mnemonic
+	_, _, addr, err := getAllFromMnemonic(tmpl)
+	return addr, mnemonic, err
 }
 
@@ -0,0 +1,42 @@
+package keykeeper
+
+import (
+	"strings"
+	"testing"
+)
+
+func testEntropyMix(t *testing.T) EntropyMix {
+	u, _ := ParseUserEntropy(EntropyDice, "12345666")
+	m, err := NewEntropyMix(u, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestGenerateMnemonicCandidate0(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		m := testEntropyMix(t)
+		_, mnemonic, index, err := GenerateMnemonic(AddrPrefix, "", MnemonicKey{Path: DefaultHDPath}, 12, m.Entropy, nil, 4)
+		if err != nil || index != 0 {
+			t.Fatal(index, err)
+		}
+		if expected, _ := m.Mnemonic(LanguageEnglish); mnemonic != expected {
+			t.Fatal(mnemonic, expected)
+		}
+	}
+}
+
+// TestGenerateMnemonicIndex checks that the returned index belongs to the returned mnemonic, run it with -race
+func TestGenerateMnemonicIndex(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		m := testEntropyMix(t)
+		addr, mnemonic, index, err := GenerateMnemonic(AddrPrefix+"q", "", MnemonicKey{Path: DefaultHDPath}, 12, m.Entropy, nil, 4)
+		if err != nil || !strings.HasPrefix(addr, AddrPrefix+"q") {
+			t.Fatal(addr, err)
+		}
+		if expected, _ := m.CandidateMnemonic(LanguageEnglish, index); mnemonic != expected {
+			t.Fatal(index, mnemonic, expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package keykeeper
+
+import "fmt"
+
+// MnemonicError tells why a mnemonic is invalid. It wraps ErrInvalidMnemonic.
+type MnemonicError struct {
+	// WordCount is the number of words, which is wrong when the other fields are not set
+	WordCount int
+	// Position is the 1-based position of the first word not in the word list, or 0
+	Position int
+	Word     string
+	// BadChecksum means all the words are known but the last one does not match the checksum
+	BadChecksum bool
+}
+
+func (e *MnemonicError) Error() string {
+	switch {
+	case e.Position != 0:
+		return fmt.Sprintf("%s: word %d \"%s\" is not in the word list", ErrInvalidMnemonic, e.Position, e.Word)
+	case e.BadChecksum:
+		return ErrInvalidMnemonic.Error() + ": the checksum does not match"
+	}
+	return fmt.Sprintf("%s: it has %d words, but must have 12, 15, 18, 21 or 24", ErrInvalidMnemonic, e.WordCount)
+}
+
+func (e *MnemonicError) Unwrap() error {
+	return ErrInvalidMnemonic
+}
+
+// MnemonicWordCounts lists the lengths of BIP39 mnemonics, each 3 words carry 32 bits of entropy and 1 bit of checksum
+var MnemonicWordCounts = []int{12, 15, 18, 21, 24}
+
+// DefaultMnemonicWords is the length of the mnemonics generated by the early releases
+const DefaultMnemonicWords = 24
+
+// MnemonicEntropyBits returns the number of entropy bits in a mnemonic of the given number of words
+func MnemonicEntropyBits(words int) (int, error) {
+	for _, n := range MnemonicWordCounts {
+		if n == words {
+			return words / 3 * 32, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", ErrInvalidWordCount, words)
+}
+
+// NormalizeMnemonic lower-cases an English mnemonic and separates its words with single spaces,
+// which is the form used to derive the seed. See Wordlist.Normalize for the other languages.
+func NormalizeMnemonic(mnemonic string) string {
+	wl, _ := GetWordlist(LanguageEnglish)
+	return wl.Normalize(mnemonic)
+}
+
+// ValidateMnemonic checks the number of words, the words and the checksum of an English mnemonic.
+// It returns a *MnemonicError for an invalid one.
+func ValidateMnemonic(mnemonic string) error {
+	wl, _ := GetWordlist(LanguageEnglish)
+	return wl.Validate(mnemonic)
+}
+
+// ImportMnemonic adds an account for a mnemonic which is already held, e.g. on paper, and saves the keybase.
+// The mnemonic is normalized and validated with the wordlist of key's language first, and the account derived
+// with key's path and BIP39 passphrase must not be in the keybase yet.
+func (kb *MyKeyBase) ImportMnemonic(memo string, key MnemonicKey, passphrase string) (AccountInfo, error) {
+	wl, err := GetWordlist(key.Language)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	key.Mnemonic = wl.Normalize(key.Mnemonic)
+	return kb.ImportKey(memo, key, passphrase)
+}
+
+func ImportMnemonic(memo string, key MnemonicKey, passphrase string) (AccountInfo, error) {
+	return KB.ImportMnemonic(memo, key, passphrase)
+}
@@ -0,0 +1,118 @@
+package keykeeper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The known answers of BIP39, whose seeds are derived with the passphrase "TREZOR". The vectors of 12, 18 and
+// 24 words are from the reference implementation of BIP39, and those of 15 and 21 words were computed with an
+// independent implementation.
+var mnemonicVectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+		seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		entropy:  "0000000000000000000000000000000000000000",
+		mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon address",
+		seed:     "fa08713f46bf5cb48728ceb70e3aae1bc53c5cb7b4e29c5610261d1cbb7be3bed4d805256fec515754d2be35974fc5da678168e9d9bb0cb70948026923b0def3",
+	},
+	{
+		entropy:  "808080808080808080808080808080808080808080808080",
+		mnemonic: "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter always",
+		seed:     "107d7c02a5aa6f38c58083ff74f04c607c2d2c0ecc55501dadd72d025b751bc27fe913ffb796f841c49b1d33b610cf0e91d3aa239027f5e99fe4ce9e5088cd65",
+	},
+	{
+		entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		mnemonic: "legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year viable",
+		seed:     "99c0597b2bef5ca4859e21075fee0fc931747a30469b6f564d95f74913c357aceb55221b4f4fe6965e871340b45754b1ae59e53da1797b69b30c5fa40ec105b8",
+	},
+	{
+		entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+		seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+	},
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	wl, err := GetWordlist(LanguageEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tested := make(map[int]bool)
+	for _, v := range mnemonicVectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		words := len(strings.Fields(v.mnemonic))
+		bits, err := MnemonicEntropyBits(words)
+		if err != nil || bits != len(entropy)*8 {
+			t.Fatal(words, bits, err)
+		}
+		mnemonic, err := wl.NewMnemonic(entropy)
+		if err != nil || mnemonic != v.mnemonic {
+			t.Fatal(words, mnemonic, err)
+		}
+		decoded, err := wl.Entropy(mnemonic)
+		if err != nil || !bytes.Equal(decoded, entropy) {
+			t.Fatal(words, err)
+		}
+		seed, _ := hex.DecodeString(v.seed)
+		if !bytes.Equal(mnemonicSeed(mnemonic, "TREZOR"), seed) {
+			t.Fatal(words, "seed")
+		}
+		tested[words] = true
+	}
+	for _, n := range MnemonicWordCounts {
+		if !tested[n] {
+			t.Fatal("no vector of", n, "words")
+		}
+	}
+}
+
+func TestGenerateMnemonicWords(t *testing.T) {
+	for _, n := range MnemonicWordCounts {
+		_, m, _, err := GenerateMnemonic("coinex1", "", MnemonicKey{Path: DefaultHDPath}, n, nil, nil, 2)
+		if err != nil || len(strings.Fields(m)) != n || ValidateMnemonic(m) != nil {
+			t.Fatal(n, m, err)
+		}
+	}
+	if _, _, _, err := GenerateMnemonic("coinex1", "", MnemonicKey{Path: DefaultHDPath}, 13, nil, nil, 2); !errors.Is(err, ErrInvalidWordCount) {
+		t.Fatal(err)
+	}
+}
+
+func TestImportMnemonic(t *testing.T) {
+	kb := openTestKeybase(t, "mem://")
+	defer kb.Close()
+	var me *MnemonicError
+	if _, err := kb.ImportMnemonic("m", MnemonicKey{Mnemonic: "abandon abandon", Path: DefaultHDPath}, "p"); !errors.As(err, &me) || me.WordCount != 2 {
+		t.Fatal(err)
+	}
+	bad := strings.Replace(testMnemonic, " art", " zzz", 1)
+	if _, err := kb.ImportMnemonic("m", MnemonicKey{Mnemonic: bad, Path: DefaultHDPath}, "p"); !errors.As(err, &me) || me.Position != 24 {
+		t.Fatal(err)
+	}
+	bad = strings.Replace(testMnemonic, " art", " abandon", 1)
+	if _, err := kb.ImportMnemonic("m", MnemonicKey{Mnemonic: bad, Path: DefaultHDPath}, "p"); !errors.As(err, &me) || !me.BadChecksum || !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatal(err)
+	}
+	messy := "  " + strings.ToUpper(strings.Replace(testMnemonic, " ", " \n\t", 3)) + " "
+	acc, err := kb.ImportMnemonic("m", MnemonicKey{Mnemonic: messy, Path: DefaultHDPath}, "p")
+	if err != nil || acc.Address != testAddress {
+		t.Fatal(err, acc.Address)
+	}
+	if m, _ := kb.GetMnemonic(acc.Address, "p"); m != testMnemonic {
+		t.Fatal(m)
+	}
+	if _, err := kb.ImportMnemonic("m", MnemonicKey{Mnemonic: testMnemonic, Path: DefaultHDPath}, "p"); !errors.Is(err, ErrAccountExists) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+// +build !windows
+
+package keykeeper
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// replaceFile renames tmpName over fname, and syncs their directory to make the rename durable
+func replaceFile(tmpName, fname string) error {
+	err := os.Rename(tmpName, fname)
+	if err != nil {
+		return err
+	}
+	d, err := os.Open(filepath.Dir(fname))
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package keykeeper
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// replaceFile moves tmpName over fname. Directories can not be synced on Windows, so MOVEFILE_WRITE_THROUGH
+// is used to return only after the move has been flushed to the disk, which os.Rename does not ask for.
+func replaceFile(tmpName, fname string) error {
+	from, err := windows.UTF16PtrFromString(tmpName)
+	if err != nil {
+		return err
+	}
+	to, err := windows.UTF16PtrFromString(fname)
+	if err != nil {
+		return err
+	}
+	return windows.MoveFileEx(from, to, windows.MOVEFILE_REPLACE_EXISTING|windows.MOVEFILE_WRITE_THROUGH)
+}
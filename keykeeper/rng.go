@@ -0,0 +1,147 @@
+package keykeeper
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// The cutoffs of the health tests follow NIST SP 800-90B section 4.4, assuming 8 bits of min-entropy per byte
+// and a false positive probability of 2^-40 per sample
+const (
+	DefaultRepetitionCutoff = 6
+	DefaultProportionWindow = 512
+	DefaultProportionCutoff = 19
+	// HealthStartupSamples are tested and discarded before the first bytes are returned
+	HealthStartupSamples = 1024
+)
+
+// SystemRandom is read for the entropy of new mnemonics, the salts, the nonces and the other random bytes.
+// It can be replaced, e.g. by a HealthCheckedReader over a fake source to see how a degenerate source is handled.
+var SystemRandom io.Reader = NewHealthCheckedReader(rand.Reader, DefaultHealthTests()...)
+
+// HealthTest is a continuous test run on every byte drawn from a random source
+type HealthTest interface {
+	// Feed checks the next sample, and returns a non-nil error when the source looks degenerate
+	Feed(sample byte) error
+}
+
+// RepetitionCountTest fails when a sample is repeated Cutoff times in a row
+type RepetitionCountTest struct {
+	Cutoff int
+	last   byte
+	count  int
+}
+
+func (t *RepetitionCountTest) Feed(sample byte) error {
+	if t.count != 0 && sample == t.last {
+		t.count++
+	} else {
+		t.last, t.count = sample, 1
+	}
+	if t.count >= t.Cutoff {
+		return fmt.Errorf("repetition count test: %d identical samples in a row", t.count)
+	}
+	return nil
+}
+
+// AdaptiveProportionTest fails when the first sample of a window occurs Cutoff times in the Window samples
+type AdaptiveProportionTest struct {
+	Window int
+	Cutoff int
+	first  byte
+	seen   int
+	count  int
+}
+
+func (t *AdaptiveProportionTest) Feed(sample byte) error {
+	if t.seen == t.Window {
+		t.seen = 0
+	}
+	if t.seen == 0 {
+		t.first, t.count = sample, 0
+	}
+	t.seen++
+	if sample == t.first {
+		t.count++
+	}
+	if t.count >= t.Cutoff {
+		return fmt.Errorf("adaptive proportion test: a sample occurs %d times in a window of %d", t.count, t.Window)
+	}
+	return nil
+}
+
+// DefaultHealthTests returns new instances of the tests used by SystemRandom
+func DefaultHealthTests() []HealthTest {
+	return []HealthTest{
+		&RepetitionCountTest{Cutoff: DefaultRepetitionCutoff},
+		&AdaptiveProportionTest{Window: DefaultProportionWindow, Cutoff: DefaultProportionCutoff},
+	}
+}
+
+// HealthCheckedReader runs the health tests on all the bytes read from its source. Once a test fails, all the
+// reads fail with an error wrapping ErrEntropyHealth, because the source can not be trusted any more.
+type HealthCheckedReader struct {
+	mu      sync.Mutex
+	src     io.Reader
+	tests   []HealthTest
+	started bool
+	err     error
+}
+
+func NewHealthCheckedReader(src io.Reader, tests ...HealthTest) *HealthCheckedReader {
+	return &HealthCheckedReader{src: src, tests: tests}
+}
+
+func (r *HealthCheckedReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.started {
+		r.started = true
+		startup := make([]byte, HealthStartupSamples)
+		r.fill(startup)
+	}
+	if r.err != nil {
+		return 0, r.err
+	}
+	n := r.fill(p)
+	if r.err != nil {
+		return 0, r.err
+	}
+	return n, nil
+}
+
+// fill reads len(p) bytes and feeds them to the tests, setting r.err on any failure
+func (r *HealthCheckedReader) fill(p []byte) int {
+	n, err := io.ReadFull(r.src, p)
+	if err != nil {
+		r.err = fmt.Errorf("%w: %v", ErrEntropyHealth, err)
+		return n
+	}
+	for _, b := range p {
+		for _, t := range r.tests {
+			if err := t.Feed(b); err != nil {
+				r.err = fmt.Errorf("%w: %v", ErrEntropyHealth, err)
+				return n
+			}
+		}
+	}
+	return n
+}
+
+// CheckSystemRandom draws a few bytes from SystemRandom, which runs the startup tests of a HealthCheckedReader
+func CheckSystemRandom() error {
+	var b [32]byte
+	_, err := io.ReadFull(SystemRandom, b[:])
+	return err
+}
+
+// newEntropy is like bip39.NewEntropy, but reads SystemRandom
+func newEntropy(bits int) ([]byte, error) {
+	entropy := make([]byte, bits/8)
+	if _, err := io.ReadFull(SystemRandom, entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
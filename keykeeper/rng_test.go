@@ -0,0 +1,145 @@
+package keykeeper
+
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"testing"
+)
+
+// firstFailure feeds the samples to a test, and returns the index of the first failed sample or -1
+func firstFailure(test HealthTest, samples []byte) int {
+	for i, b := range samples {
+		if test.Feed(b) != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// countingSamples returns n samples, which never repeat in a row and never return to their first value
+// unless the index is in at
+func countingSamples(n int, at ...int) []byte {
+	samples := make([]byte, n)
+	for i := range samples {
+		samples[i] = byte(1 + i%255)
+	}
+	for _, i := range at {
+		samples[i] = 0
+	}
+	samples[0] = 0
+	return samples
+}
+
+func repeated(b byte, n int) []byte {
+	res := make([]byte, n)
+	for i := range res {
+		res[i] = b
+	}
+	return res
+}
+
+func TestRepetitionCountTest(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		samples []byte
+		failAt  int
+	}{
+		{"constant", repeated(7, 100), DefaultRepetitionCutoff - 1},
+		{"stuck below the cutoff", append(append([]byte{1, 2}, repeated(9, DefaultRepetitionCutoff-1)...), 3, 9), -1},
+		{"stuck at the cutoff", append([]byte{1, 2}, repeated(9, DefaultRepetitionCutoff)...), DefaultRepetitionCutoff + 1},
+		{"runs broken by other samples", append(append(repeated(4, 5), 5), repeated(4, 5)...), -1},
+		{"counting", countingSamples(2000), -1},
+	} {
+		test := &RepetitionCountTest{Cutoff: DefaultRepetitionCutoff}
+		if i := firstFailure(test, c.samples); i != c.failAt {
+			t.Fatalf("%s: failed at %d instead of %d", c.name, i, c.failAt)
+		}
+	}
+}
+
+func TestAdaptiveProportionTest(t *testing.T) {
+	// the positions of the first sample of the window repeated in it, evenly spread
+	spread := func(n, offset int) []int {
+		var at []int
+		for i := 1; i <= n; i++ {
+			at = append(at, offset+i*(DefaultProportionWindow-1)/n)
+		}
+		return at
+	}
+	below := DefaultProportionCutoff - 1
+	for _, c := range []struct {
+		name    string
+		samples []byte
+		failAt  int
+	}{
+		{"counting", countingSamples(4 * DefaultProportionWindow), -1},
+		// the first sample and its repetitions
+		{"biased below the cutoff", countingSamples(DefaultProportionWindow, spread(below-1, 0)...), -1},
+		{"biased at the cutoff", countingSamples(DefaultProportionWindow, spread(below, 0)...), spread(below, 0)[below-1]},
+		// the count starts again in the next window, whose first sample is 0 as well
+		{"biased in two windows", countingSamples(2*DefaultProportionWindow,
+			append(append(spread(below-1, 0), DefaultProportionWindow), spread(below-1, DefaultProportionWindow)...)...), -1},
+		{"biased at the cutoff in the second window", countingSamples(2*DefaultProportionWindow,
+			append(append(spread(below-1, 0), DefaultProportionWindow), spread(below, DefaultProportionWindow)...)...),
+			DefaultProportionWindow + spread(below, 0)[below-1]},
+		{"constant", repeated(7, 100), DefaultProportionCutoff - 1},
+	} {
+		test := &AdaptiveProportionTest{Window: DefaultProportionWindow, Cutoff: DefaultProportionCutoff}
+		if i := firstFailure(test, c.samples); i != c.failAt {
+			t.Fatalf("%s: failed at %d instead of %d", c.name, i, c.failAt)
+		}
+	}
+}
+
+// constReader is a broken source which returns the same byte forever
+type constReader byte
+
+func (r constReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestHealthCheckedReader(t *testing.T) {
+	buf := make([]byte, 64)
+	good := NewHealthCheckedReader(rand.Reader, DefaultHealthTests()...)
+	for i := 0; i < 100; i++ {
+		if _, err := io.ReadFull(good, buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a bad source fails in the startup tests, and then stays failed
+	bad := NewHealthCheckedReader(constReader(0), DefaultHealthTests()...)
+	for i := 0; i < 2; i++ {
+		if n, err := bad.Read(buf); n != 0 || !errors.Is(err, ErrEntropyHealth) {
+			t.Fatal(n, err)
+		}
+	}
+	short := NewHealthCheckedReader(io.LimitReader(rand.Reader, HealthStartupSamples+10), DefaultHealthTests()...)
+	if _, err := short.Read(buf); !errors.Is(err, ErrEntropyHealth) {
+		t.Fatal(err)
+	}
+}
+
+func TestBadSystemRandom(t *testing.T) {
+	kb := openTestKeybase(t, "mem://")
+	defer kb.Close()
+	saved := SystemRandom
+	defer func() { SystemRandom = saved }()
+	SystemRandom = NewHealthCheckedReader(constReader(0xff), DefaultHealthTests()...)
+
+	if err := CheckSystemRandom(); !errors.Is(err, ErrEntropyHealth) {
+		t.Fatal(err)
+	}
+	if _, _, _, err := GenerateMnemonic("coinex1", "", MnemonicKey{Path: DefaultHDPath}, 24, nil, nil, 1); !errors.Is(err, ErrEntropyHealth) {
+		t.Fatal(err)
+	}
+	if _, err := kb.CreateAccount("memo", testMnemonic, "p"); !errors.Is(err, ErrEntropyHealth) {
+		t.Fatal(err)
+	}
+	if kb.HasAccount(testAddress) {
+		t.Fatal("an account is created with a bad source")
+	}
+}
@@ -0,0 +1,174 @@
+package keykeeper
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// PassphrasePrompt is called by OpenKeybase to ask the user for the keybase-level passphrase,
+// only when the keybase has one
+type PassphrasePrompt func() (string, error)
+
+// KeybaseSecret describes how the keybase key is derived from the keybase-level passphrase.
+// The key is used to seal the whole keybase and to authenticate its accounts.
+type KeybaseSecret struct {
+	Kdf             *KdfParams `json:"kdf"`
+	PassphraseCksum []byte     `json:"passphrase_cksum"`
+}
+
+// sealedPayload is the plaintext of a sealed keybase.
+// Format version 2 sealed a bare array of accounts instead.
+type sealedPayload struct {
+	Accounts  []AccountInfo      `json:"accounts"`
+	Wallets   []WalletInfo       `json:"wallets,omitempty"`
+	Integrity *IntegrityManifest `json:"integrity,omitempty"`
+}
+
+// keybaseKey keeps the derived key in memory, so that each Save does not run the KDF again
+type keybaseKey struct {
+	secret KeybaseSecret
+	key    []byte
+}
+
+func newKeybaseKey(passphrase string, kdfTmpl KdfParams) (*keybaseKey, error) {
+	kdf, err := NewKdfParams(kdfTmpl)
+	if err != nil {
+		return nil, err
+	}
+	key, cksum, err := kdf.deriveKeys(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &keybaseKey{secret: KeybaseSecret{Kdf: kdf, PassphraseCksum: cksum}, key: key}, nil
+}
+
+func openKeybaseKey(secret *KeybaseSecret, passphrase string) (*keybaseKey, error) {
+	key, ok := secret.Kdf.checkCksum(passphrase, secret.PassphraseCksum)
+	if !ok {
+		return nil, ErrBadKeybasePassphrase
+	}
+	return &keybaseKey{secret: *secret, key: key}, nil
+}
+
+func (k *keybaseKey) check(passphrase string) error {
+	if _, ok := k.secret.Kdf.checkCksum(passphrase, k.secret.PassphraseCksum); !ok {
+		return ErrBadKeybasePassphrase
+	}
+	return nil
+}
+
+// macKey is separated from the encryption key, which is used directly by AES-GCM
+func (k *keybaseKey) macKey() []byte {
+	mac := hmac.New(sha256.New, k.key)
+	mac.Write([]byte("ColdWallet.win keybase MAC"))
+	return mac.Sum(nil)
+}
+
+func (k *keybaseKey) seal(payload sealedPayload) ([]byte, error) {
+	plaintext, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, nonce, err := AesGcmEncrypt(k.key, string(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, ciphertext...), nil
+}
+
+func (k *keybaseKey) unseal(sealed []byte) (payload sealedPayload, err error) {
+	if len(sealed) < AesNonceLength {
+		err = fmt.Errorf("%w: the sealed data is truncated", ErrInvalidKeybase)
+		return
+	}
+	nonce := sealed[:AesNonceLength]
+	plaintext, err := AesGcmDecrypt(k.key, sealed[AesNonceLength:], nonce)
+	if err != nil {
+		return
+	}
+	if bytes.HasPrefix([]byte(plaintext), []byte("[")) {
+		err = json.Unmarshal([]byte(plaintext), &payload.Accounts)
+		return
+	}
+	err = json.Unmarshal([]byte(plaintext), &payload)
+	return
+}
+
+type loadedKeybase struct {
+	header   KeybaseHeader
+	accounts []AccountInfo
+	wallets  []WalletInfo
+	key      *keybaseKey
+	sealed   bool
+	// manifest is only set when the accounts match it
+	manifest *IntegrityManifest
+}
+
+// macs returns the MACs of the accounts from the verified manifest
+func (lk loadedKeybase) macs() map[string][]byte {
+	res := make(map[string][]byte, len(lk.accounts))
+	if lk.manifest != nil {
+		for _, entry := range lk.manifest.Entries {
+			res[entry.Address] = entry.Mac
+		}
+	}
+	return res
+}
+
+// loadKeybase decodes the content of a keybase file and then calls loadContent
+func loadKeybase(content []byte, prompt PassphrasePrompt) (lk loadedKeybase, err error) {
+	kf, err := decodeKeybase(content)
+	if err != nil {
+		return
+	}
+	return loadContent(kf, prompt)
+}
+
+// loadContent opens the content loaded from a storage. If it has a keybase passphrase, prompt is
+// called to get it, and the accounts are checked against the integrity manifest. When they do not
+// match, or the passphrase has been removed from a protected keybase, a *TamperError is returned
+// along with the fully loaded keybase.
+func loadContent(kf KeybaseContent, prompt PassphrasePrompt) (lk loadedKeybase, err error) {
+	lk.header = kf.KeybaseHeader
+	if kf.Secret == nil {
+		lk.accounts, lk.wallets = kf.Accounts, kf.Wallets
+		if kf.Protected {
+			err = &TamperError{ProtectionRemoved: true}
+		}
+		return
+	}
+	if prompt == nil {
+		err = ErrKeybasePassphraseNeeded
+		return
+	}
+	passphrase, err := prompt()
+	if err != nil {
+		return
+	}
+	lk.key, err = openKeybaseKey(kf.Secret, passphrase)
+	if err != nil {
+		return
+	}
+	lk.accounts, lk.wallets, lk.sealed = kf.Accounts, kf.Wallets, kf.Sealed != nil
+	manifest := kf.Integrity
+	if lk.sealed {
+		var payload sealedPayload
+		payload, err = lk.key.unseal(kf.Sealed)
+		if err != nil {
+			return
+		}
+		lk.accounts, lk.wallets, manifest = payload.Accounts, payload.Wallets, payload.Integrity
+		if manifest == nil {
+			// sealed by format version 2, which is authenticated by AES-GCM only
+			return
+		}
+	}
+	err = verifyManifest(lk.key.macKey(), lk.header.Kdf, lk.accounts, lk.wallets, manifest)
+	if err == nil {
+		lk.manifest = manifest
+	}
+	return
+}
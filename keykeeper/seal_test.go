@@ -0,0 +1,53 @@
+package keykeeper
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSeal(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "seal")
+	defer os.RemoveAll(dir)
+	location := filepath.Join(dir, "kb.json")
+	kb := openTestKeybase(t, location)
+	acc, _ := kb.CreateAccount("secret memo", testMnemonic, "p")
+	if err := kb.Seal(""); !errors.Is(err, ErrEmptyPassphrase) {
+		t.Fatal(err)
+	}
+	if kb.IsSealed() || kb.HasKeybasePassphrase() {
+		t.Fatal("sealed under an empty passphrase")
+	}
+	if err := kb.Seal("kbp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.SetKeybasePassphrase("kbp", ""); !errors.Is(err, ErrUnsealFirst) {
+		t.Fatal(err)
+	}
+	kb.Close()
+
+	content, _ := ioutil.ReadFile(location)
+	if strings.Contains(string(content), acc.Address) || strings.Contains(string(content), "secret memo") {
+		t.Fatal("the sealed keybase has the account in plaintext")
+	}
+	if _, err := Open(location, OpenOptions{}); !errors.Is(err, ErrKeybasePassphraseNeeded) {
+		t.Fatal(err)
+	}
+	if _, err := Open(location, OpenOptions{Prompt: func() (string, error) { return "bad", nil }}); !errors.Is(err, ErrBadKeybasePassphrase) {
+		t.Fatal(err)
+	}
+	kb, err := Open(location, OpenOptions{Prompt: func() (string, error) { return "kbp", nil }})
+	if err != nil || !kb.HasAccount(acc.Address) {
+		t.Fatal(err)
+	}
+	if err := kb.Unseal("kbp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := kb.SetKeybasePassphrase("kbp", ""); err != nil {
+		t.Fatal(err)
+	}
+	kb.Close()
+}
@@ -0,0 +1,69 @@
+package keykeeper
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// URI schemes accepted by NewStorage
+const (
+	FileScheme = "file"
+	DirScheme  = "dir"
+	MemScheme  = "mem"
+)
+
+// Storage is where the content of a keybase is kept.
+// Lock and Unlock are called by read-write opens only, and Lock returns ErrKeybaseLocked if it is held elsewhere.
+type Storage interface {
+	// String returns the location shown to users
+	String() string
+	Lock() error
+	Unlock() error
+	// Load returns exists=false if nothing has been stored yet
+	Load() (kc KeybaseContent, exists bool, err error)
+	Store(kc KeybaseContent) error
+}
+
+// BackupStorage is implemented by the storages which keep backups of the previous content on each Store
+type BackupStorage interface {
+	Storage
+	// SetBackupGenerations sets how many backups are kept, zero disables backups
+	SetBackupGenerations(n int)
+	ListBackups() ([]BackupInfo, error)
+	RemoveBackups() error
+}
+
+// IncrementalStorage is implemented by the storages which can store the changed accounts only
+type IncrementalStorage interface {
+	Storage
+	// StoreChanges is like Store, but the caller promises that only the accounts whose addresses are in
+	// changed have been added, modified or deleted since the last Load or Store
+	StoreChanges(kc KeybaseContent, changed map[string]bool) error
+}
+
+// NewStorage chooses the storage from the scheme of location: "file://path", "dir://path" or "mem://name".
+// Without a scheme, an existing directory or a path ending with a separator uses DirStorage,
+// and any other path uses FileStorage.
+func NewStorage(location string) (Storage, error) {
+	if i := strings.Index(location, "://"); i > 0 {
+		scheme, path := location[:i], location[i+3:]
+		switch scheme {
+		case FileScheme:
+			return NewFileStorage(path), nil
+		case DirScheme:
+			return NewDirStorage(path), nil
+		case MemScheme:
+			return NewMemStorage(path), nil
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, scheme)
+		}
+	}
+	if info, err := os.Stat(location); err == nil && info.IsDir() {
+		return NewDirStorage(location), nil
+	}
+	if strings.HasSuffix(location, "/") || strings.HasSuffix(location, string(os.PathSeparator)) {
+		return NewDirStorage(location), nil
+	}
+	return NewFileStorage(location), nil
+}
@@ -0,0 +1,236 @@
+package keykeeper
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	dirIndexName   = "keybase.json"
+	dirAccountsDir = "accounts"
+	dirAccountExt  = ".json"
+)
+
+// DirStorage keeps a keybase in a directory: the header, the keybase secret, the wallets and the integrity manifest
+// in "keybase.json", and each account in its own file "accounts/<address>.json", whose mnemonic is
+// encrypted as in a keybase file. So single accounts can be copied and backed up.
+// An account file copied into "accounts" is loaded after the listed ones, and it is reported by the
+// integrity check if the keybase has a passphrase. A file whose name is not the address of its account
+// is refused, because Store would never remove it. A sealed keybase has no account files.
+type DirStorage struct {
+	dir  string
+	lock *fileLock
+}
+
+var _ IncrementalStorage = (*DirStorage)(nil)
+
+// dirIndex is the content of keybase.json, Order keeps the order of the accounts
+type dirIndex struct {
+	KeybaseContent
+	Order []string `json:"order,omitempty"`
+}
+
+func NewDirStorage(dir string) *DirStorage {
+	return &DirStorage{dir: filepath.Clean(dir)}
+}
+
+func (s *DirStorage) String() string {
+	return s.dir
+}
+
+func (s *DirStorage) indexName() string {
+	return filepath.Join(s.dir, dirIndexName)
+}
+
+func (s *DirStorage) accountName(addr string) (string, error) {
+	if addr == "" || strings.HasPrefix(addr, ".") || strings.ContainsAny(addr, `/\:`) {
+		return "", fmt.Errorf("%w: bad account address %q", ErrInvalidKeybase, addr)
+	}
+	return filepath.Join(s.dir, dirAccountsDir, addr+dirAccountExt), nil
+}
+
+func (s *DirStorage) Lock() error {
+	err := os.MkdirAll(s.dir, 0700)
+	if err != nil {
+		return err
+	}
+	lock, err := lockKeybase(s.indexName())
+	if err != nil {
+		return err
+	}
+	s.lock = lock
+	return nil
+}
+
+func (s *DirStorage) Unlock() error {
+	err := s.lock.unlock()
+	s.lock = nil
+	return err
+}
+
+func (s *DirStorage) Load() (kc KeybaseContent, exists bool, err error) {
+	content, err := ioutil.ReadFile(s.indexName())
+	if os.IsNotExist(err) {
+		return kc, false, nil
+	}
+	if err != nil {
+		return
+	}
+	content, err = migrateKeybase(content)
+	if err != nil {
+		return
+	}
+	var index dirIndex
+	err = json.Unmarshal(content, &index)
+	if err != nil {
+		return
+	}
+	kc = index.KeybaseContent
+	if kc.Sealed != nil {
+		return kc, true, nil
+	}
+	kc.Accounts, err = s.loadAccounts(index.Order)
+	return kc, err == nil, err
+}
+
+func (s *DirStorage) loadAccounts(order []string) ([]AccountInfo, error) {
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, dirAccountsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	present := make(map[string]bool, len(files))
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), dirAccountExt) {
+			present[strings.TrimSuffix(f.Name(), dirAccountExt)] = true
+		}
+	}
+	// the listed accounts come first, in their order, and then the unlisted ones sorted by address
+	var addrs []string
+	for _, addr := range order {
+		if present[addr] {
+			addrs = append(addrs, addr)
+			delete(present, addr)
+		}
+	}
+	var unlisted []string
+	for addr := range present {
+		unlisted = append(unlisted, addr)
+	}
+	sort.Strings(unlisted)
+	addrs = append(addrs, unlisted...)
+
+	accounts := make([]AccountInfo, 0, len(addrs))
+	for _, addr := range addrs {
+		fname, err := s.accountName(addr)
+		if err != nil {
+			return nil, err
+		}
+		content, err := ioutil.ReadFile(fname)
+		if err != nil {
+			return nil, err
+		}
+		var acc AccountInfo
+		err = json.Unmarshal(content, &acc)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKeybase, fname, err)
+		}
+		if acc.Address != addr {
+			return nil, fmt.Errorf("%w: %s holds the account %s", ErrInvalidKeybase, fname, acc.Address)
+		}
+		accounts = append(accounts, acc)
+	}
+	return accounts, nil
+}
+
+// Store writes all the account files before keybase.json, and removes the stale account files at last,
+// so an interrupted Store never loses an account
+func (s *DirStorage) Store(kc KeybaseContent) error {
+	keep := make(map[string]bool, len(kc.Accounts))
+	for _, acc := range kc.Accounts {
+		keep[acc.Address+dirAccountExt] = true
+	}
+	err := s.store(kc, func(addr string) bool { return true })
+	if err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(filepath.Join(s.dir, dirAccountsDir))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), dirAccountExt) && !keep[f.Name()] {
+			err = os.Remove(filepath.Join(s.dir, dirAccountsDir, f.Name()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// StoreChanges only writes the changed account files and keybase.json
+func (s *DirStorage) StoreChanges(kc KeybaseContent, changed map[string]bool) error {
+	err := s.store(kc, func(addr string) bool { return changed[addr] })
+	if err != nil {
+		return err
+	}
+	present := make(map[string]bool, len(changed))
+	for _, acc := range kc.Accounts {
+		if changed[acc.Address] {
+			present[acc.Address] = true
+		}
+	}
+	for addr := range changed {
+		if present[addr] {
+			continue
+		}
+		fname, err := s.accountName(addr)
+		if err != nil {
+			return err
+		}
+		err = os.Remove(fname)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// store writes the account files selected by write, and then keybase.json
+func (s *DirStorage) store(kc KeybaseContent, write func(addr string) bool) error {
+	err := os.MkdirAll(filepath.Join(s.dir, dirAccountsDir), 0700)
+	if err != nil {
+		return err
+	}
+	index := dirIndex{KeybaseContent: kc, Order: make([]string, 0, len(kc.Accounts))}
+	index.Accounts = nil
+	for _, acc := range kc.Accounts {
+		index.Order = append(index.Order, acc.Address)
+		if !write(acc.Address) {
+			continue
+		}
+		fname, err := s.accountName(acc.Address)
+		if err != nil {
+			return err
+		}
+		b, err := json.Marshal(acc)
+		if err != nil {
+			return err
+		}
+		err = writeFileAtomic(fname, b)
+		if err != nil {
+			return err
+		}
+	}
+	index.Version = KeybaseFormatVersion
+	b, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.indexName(), b)
+}
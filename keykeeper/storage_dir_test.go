@@ -0,0 +1,135 @@
+package keykeeper
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testDirContent(addrs ...string) KeybaseContent {
+	kc := KeybaseContent{KeybaseHeader: NewKeybaseHeader()}
+	for _, addr := range addrs {
+		kc.Accounts = append(kc.Accounts, AccountInfo{Address: addr, Memo: "memo of " + addr})
+	}
+	return kc
+}
+
+func loadedAddrs(t *testing.T, s *DirStorage) []string {
+	kc, exists, err := s.Load()
+	if err != nil || !exists {
+		t.Fatal(err, exists)
+	}
+	var addrs []string
+	for _, acc := range kc.Accounts {
+		addrs = append(addrs, acc.Address)
+	}
+	return addrs
+}
+
+// writeAccountFile writes an account file without keybase.json, as an interrupted Store or a user copying files does
+func writeAccountFile(t *testing.T, s *DirStorage, name string, acc AccountInfo) {
+	b, _ := json.Marshal(acc)
+	err := ioutil.WriteFile(filepath.Join(s.dir, dirAccountsDir, name+dirAccountExt), b, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDirStorageRoundTrip(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dirstorage")
+	defer os.RemoveAll(dir)
+	s := NewDirStorage(dir)
+	if _, exists, err := s.Load(); exists || err != nil {
+		t.Fatal(exists, err)
+	}
+	kc := testDirContent("coinex1b", "coinex1a", "coinex1c")
+	if err := s.Store(kc); err != nil {
+		t.Fatal(err)
+	}
+	loaded, _, err := s.Load()
+	if err != nil || !reflect.DeepEqual(loaded.Accounts, kc.Accounts) {
+		t.Fatal(err, loaded.Accounts)
+	}
+
+	kc = testDirContent("coinex1c", "coinex1d")
+	kc.Accounts[0].Memo = "changed"
+	if err := s.StoreChanges(kc, map[string]bool{"coinex1a": true, "coinex1c": true, "coinex1d": true}); err != nil {
+		t.Fatal(err)
+	}
+	// coinex1b is unchanged, so StoreChanges keeps its file, and it comes back as an unlisted account
+	loaded, _, err = s.Load()
+	if err != nil || !reflect.DeepEqual(loaded.Accounts, append(kc.Accounts, AccountInfo{Address: "coinex1b", Memo: "memo of coinex1b"})) {
+		t.Fatal(err, loaded.Accounts)
+	}
+	if err := s.Store(kc); err != nil {
+		t.Fatal(err)
+	}
+	if addrs := loadedAddrs(t, s); !reflect.DeepEqual(addrs, []string{"coinex1c", "coinex1d"}) {
+		t.Fatal(addrs)
+	}
+}
+
+func TestDirStorageUnlisted(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dirstorage")
+	defer os.RemoveAll(dir)
+	s := NewDirStorage(dir)
+	if err := s.Store(testDirContent("coinex1m", "coinex1k")); err != nil {
+		t.Fatal(err)
+	}
+	// the unlisted accounts come after the listed ones, sorted by address, and other files are ignored
+	writeAccountFile(t, s, "coinex1z", AccountInfo{Address: "coinex1z"})
+	writeAccountFile(t, s, "coinex1a", AccountInfo{Address: "coinex1a"})
+	ioutil.WriteFile(filepath.Join(dir, dirAccountsDir, "notes.txt"), []byte("x"), 0600)
+	os.Mkdir(filepath.Join(dir, dirAccountsDir, "old"+dirAccountExt), 0700)
+	if addrs := loadedAddrs(t, s); !reflect.DeepEqual(addrs, []string{"coinex1m", "coinex1k", "coinex1a", "coinex1z"}) {
+		t.Fatal(addrs)
+	}
+}
+
+func TestDirStorageMismatchedName(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dirstorage")
+	defer os.RemoveAll(dir)
+	s := NewDirStorage(dir)
+	kc := testDirContent("coinex1a")
+	if err := s.Store(kc); err != nil {
+		t.Fatal(err)
+	}
+	writeAccountFile(t, s, "copy", kc.Accounts[0])
+	if _, _, err := s.Load(); !errors.Is(err, ErrInvalidKeybase) {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(dir, dirAccountsDir, "copy"+dirAccountExt))
+	// a listed name holding another account is refused as well
+	writeAccountFile(t, s, "coinex1a", AccountInfo{Address: "coinex1b"})
+	if _, _, err := s.Load(); !errors.Is(err, ErrInvalidKeybase) {
+		t.Fatal(err)
+	}
+}
+
+func TestDirStorageInterruptedStore(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "dirstorage")
+	defer os.RemoveAll(dir)
+	s := NewDirStorage(dir)
+	if err := s.Store(testDirContent("coinex1a", "coinex1b")); err != nil {
+		t.Fatal(err)
+	}
+	// a Store of [b, c] interrupted after writing the account files, before keybase.json
+	writeAccountFile(t, s, "coinex1c", AccountInfo{Address: "coinex1c"})
+	if addrs := loadedAddrs(t, s); !reflect.DeepEqual(addrs, []string{"coinex1a", "coinex1b", "coinex1c"}) {
+		t.Fatal(addrs)
+	}
+	// interrupted after writing keybase.json, before removing the stale file of a
+	index := dirIndex{KeybaseContent: testDirContent(), Order: []string{"coinex1b", "coinex1c"}}
+	index.Version = KeybaseFormatVersion
+	b, _ := json.Marshal(index)
+	if err := ioutil.WriteFile(s.indexName(), b, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if addrs := loadedAddrs(t, s); !reflect.DeepEqual(addrs, []string{"coinex1b", "coinex1c", "coinex1a"}) {
+		t.Fatal(addrs)
+	}
+}
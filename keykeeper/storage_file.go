@@ -0,0 +1,88 @@
+package keykeeper
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// FileStorage keeps the whole keybase in one JSON file, which is replaced atomically on each Store.
+// The previous contents are kept as backups next to it.
+type FileStorage struct {
+	fname       string
+	generations int
+	lock        *fileLock
+}
+
+var _ BackupStorage = (*FileStorage)(nil)
+
+func NewFileStorage(fname string) *FileStorage {
+	return &FileStorage{fname: fname, generations: DefaultBackupGenerations}
+}
+
+func (s *FileStorage) String() string {
+	return s.fname
+}
+
+func (s *FileStorage) Lock() error {
+	lock, err := lockKeybase(s.fname)
+	if err != nil {
+		return err
+	}
+	s.lock = lock
+	return nil
+}
+
+func (s *FileStorage) Unlock() error {
+	err := s.lock.unlock()
+	s.lock = nil
+	return err
+}
+
+// Load reads the file with O_RDONLY, and an empty file is taken as a new keybase
+func (s *FileStorage) Load() (kc KeybaseContent, exists bool, err error) {
+	info, err := os.Stat(s.fname)
+	if os.IsNotExist(err) {
+		return kc, false, nil
+	}
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		err = &os.PathError{Op: "open", Path: s.fname, Err: ErrNotPlainFile}
+		return
+	}
+	f, err := os.OpenFile(s.fname, os.O_RDONLY, 0)
+	if err != nil {
+		return
+	}
+	content, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return
+	}
+	if len(content) == 0 {
+		return KeybaseContent{KeybaseHeader: NewKeybaseHeader()}, true, nil
+	}
+	kc, err = decodeKeybase(content)
+	return kc, err == nil, err
+}
+
+func (s *FileStorage) Store(kc KeybaseContent) error {
+	b, err := encodeKeybase(kc)
+	if err != nil {
+		return err
+	}
+	return backupAndWrite(s.fname, b, s.generations)
+}
+
+func (s *FileStorage) SetBackupGenerations(n int) {
+	s.generations = n
+}
+
+func (s *FileStorage) ListBackups() ([]BackupInfo, error) {
+	return ListBackups(s.fname)
+}
+
+func (s *FileStorage) RemoveBackups() error {
+	return removeBackups(s.fname)
+}
@@ -0,0 +1,78 @@
+package keykeeper
+
+import (
+	"sync"
+)
+
+// MemStorage keeps the encoded keybase in memory, for tests and for embedding keykeeper in other tools.
+// The content goes through the same encoding as a keybase file.
+type MemStorage struct {
+	mtx     sync.Mutex
+	name    string
+	content []byte
+	locked  bool
+}
+
+var (
+	memStoragesMtx sync.Mutex
+	memStorages    = make(map[string]*MemStorage)
+)
+
+// NewMemStorage returns the storage registered with name, so that "mem://name" can be opened again
+// in the same process. An empty name gives a new anonymous storage.
+func NewMemStorage(name string) *MemStorage {
+	if name == "" {
+		return &MemStorage{}
+	}
+	memStoragesMtx.Lock()
+	defer memStoragesMtx.Unlock()
+	s, ok := memStorages[name]
+	if !ok {
+		s = &MemStorage{name: name}
+		memStorages[name] = s
+	}
+	return s
+}
+
+func (s *MemStorage) String() string {
+	return MemScheme + "://" + s.name
+}
+
+func (s *MemStorage) Lock() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.locked {
+		return ErrKeybaseLocked
+	}
+	s.locked = true
+	return nil
+}
+
+func (s *MemStorage) Unlock() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.locked = false
+	return nil
+}
+
+func (s *MemStorage) Load() (kc KeybaseContent, exists bool, err error) {
+	s.mtx.Lock()
+	content := s.content
+	s.mtx.Unlock()
+	if content == nil {
+		return kc, false, nil
+	}
+	kc, err = decodeKeybase(content)
+	return kc, err == nil, err
+}
+
+func (s *MemStorage) Store(kc KeybaseContent) error {
+	b, err := encodeKeybase(kc)
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.content = b
+	return nil
+}
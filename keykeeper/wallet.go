@@ -0,0 +1,323 @@
+package keykeeper
+
+// WalletInfo is an HD wallet, which stores one encrypted mnemonic for many child accounts.
+// The children are AccountInfo whose Wallet field is the wallet's ID. They are derived with
+// m/44'/CoinType'/Account'/0/index, and they use the wallet's passphrase.
+type WalletInfo struct {
+	// ID is the address of the child at index 0, which is unique for a mnemonic, a coin type and an account
+	ID                string     `json:"id"`
+	Memo              string     `json:"memo"`
+	CoinType          uint32     `json:"coin_type"`
+	Account           uint32     `json:"account"`
+	NextIndex         uint32     `json:"next_index"`
+	Kdf               *KdfParams `json:"kdf"`
+	PassphraseCksum   []byte     `json:"passphrase_cksum"`
+	EncryptedMnemonic []byte     `json:"encrypted_mnemonic"`
+	// Language is the language of the mnemonic's wordlist, and it is empty for English
+	Language string `json:"language,omitempty"`
+	// EncryptedBIP39Passphrase is shared by all the children, see AccountInfo
+	EncryptedBIP39Passphrase []byte `json:"encrypted_bip39_passphrase,omitempty"`
+}
+
+// NewWalletInfo creates a wallet without children. The coin type and the account of key's path are used
+// for all the children, and its index is ignored.
+func NewWalletInfo(memo string, key MnemonicKey, passphrase string, kdfTmpl KdfParams) (WalletInfo, error) {
+	if err := validateKey(key); err != nil {
+		return WalletInfo{}, err
+	}
+	w := WalletInfo{Memo: memo, CoinType: key.Path.CoinType, Account: key.Path.Account, Language: key.storedLanguage()}
+	key.Path = w.ChildPath(0)
+	_, _, id, err := getAllFromMnemonic(key)
+	if err != nil {
+		return WalletInfo{}, err
+	}
+	w.ID = id
+	err = w.encryptMnemonicKey(key, passphrase, kdfTmpl)
+	return w, err
+}
+
+func (w *WalletInfo) encryptMnemonicKey(key MnemonicKey, passphrase string, kdfTmpl KdfParams) error {
+	kdf, cksum, ciphertexts, err := encryptSecrets(passphrase, kdfTmpl, key.Mnemonic, key.BIP39Passphrase)
+	if err != nil {
+		return err
+	}
+	w.Kdf, w.PassphraseCksum = kdf, cksum
+	w.EncryptedMnemonic, w.EncryptedBIP39Passphrase = ciphertexts[0], ciphertexts[1]
+	return nil
+}
+
+// ChildPath returns the HD path of the child at index
+func (w WalletInfo) ChildPath(index uint32) HDPath {
+	return HDPath{CoinType: w.CoinType, Account: w.Account, Index: index}
+}
+
+func (w WalletInfo) CheckPassphrase(passphrase string) error {
+	if _, ok := w.Kdf.checkCksum(passphrase, w.PassphraseCksum); !ok {
+		return accountError(w.ID, ErrBadPassphrase)
+	}
+	return nil
+}
+
+// decryptMnemonicKey returns the key of the child at index 0
+func (w WalletInfo) decryptMnemonicKey(passphrase string) (MnemonicKey, error) {
+	if w.EncryptedMnemonic == nil {
+		return MnemonicKey{}, accountError(w.ID, ErrCorruptedAccount)
+	}
+	secrets, err := decryptSecrets(w.Kdf, w.PassphraseCksum, passphrase, w.EncryptedMnemonic, w.EncryptedBIP39Passphrase)
+	if err != nil {
+		return MnemonicKey{}, accountError(w.ID, err)
+	}
+	return MnemonicKey{Mnemonic: secrets[0], Path: w.ChildPath(0), BIP39Passphrase: secrets[1], Language: w.Language}, nil
+}
+
+// GetWallet returns the wallet with id
+func (kb *MyKeyBase) GetWallet(id string) (WalletInfo, bool) {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	for _, w := range kb.wallets {
+		if w.ID == id {
+			return w, true
+		}
+	}
+	return WalletInfo{}, false
+}
+
+func (kb *MyKeyBase) ListWallets() []WalletInfo {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	return append([]WalletInfo(nil), kb.wallets...)
+}
+
+// ListWalletChildren returns the children of a wallet, in the order they were added
+func (kb *MyKeyBase) ListWalletChildren(id string) (children []AccountInfo) {
+	kb.mtx.RLock()
+	defer kb.mtx.RUnlock()
+	for _, acc := range kb.Accounts {
+		if acc.Wallet == id {
+			children = append(children, acc)
+		}
+	}
+	return
+}
+
+// CreateWallet adds a wallet without children and saves the keybase. Use AddWalletChild to derive its accounts.
+func (kb *MyKeyBase) CreateWallet(memo string, key MnemonicKey, passphrase string) (WalletInfo, error) {
+	w, err := NewWalletInfo(memo, key, passphrase, kb.Header().Kdf)
+	if err != nil {
+		return w, err
+	}
+	err = kb.putWallet(w, true)
+	if err != nil {
+		return w, err
+	}
+	return w, kb.Save()
+}
+
+// putWallet adds w or replaces the wallet with the same ID
+func (kb *MyKeyBase) putWallet(w WalletInfo, add bool) error {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	for i := range kb.wallets {
+		if kb.wallets[i].ID == w.ID {
+			if add {
+				return accountError(w.ID, ErrWalletExists)
+			}
+			kb.wallets[i] = w
+			return nil
+		}
+	}
+	if !add {
+		return accountError(w.ID, ErrNoSuchWallet)
+	}
+	kb.wallets = append(kb.wallets, w)
+	return nil
+}
+
+// AddWalletChild derives the child at the wallet's next index, adds it as an account with memo, and saves the keybase
+func (kb *MyKeyBase) AddWalletChild(id, passphrase, memo string) (AccountInfo, error) {
+	w, ok := kb.GetWallet(id)
+	if !ok {
+		return AccountInfo{}, accountError(id, ErrNoSuchWallet)
+	}
+	key, err := w.decryptMnemonicKey(passphrase)
+	if err != nil {
+		return AccountInfo{}, err
+	}
+	child, err := kb.addWalletChild(id, key, memo)
+	if err != nil {
+		return child, err
+	}
+	return child, kb.Save()
+}
+
+// addWalletChild takes the next index under the lock, so two children never get the same one
+func (kb *MyKeyBase) addWalletChild(id string, key MnemonicKey, memo string) (AccountInfo, error) {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return AccountInfo{}, ErrReadOnly
+	}
+	var wallet *WalletInfo
+	for i := range kb.wallets {
+		if kb.wallets[i].ID == id {
+			wallet = &kb.wallets[i]
+		}
+	}
+	if wallet == nil {
+		return AccountInfo{}, accountError(id, ErrNoSuchWallet)
+	}
+	for {
+		path := wallet.ChildPath(wallet.NextIndex)
+		key.Path = path
+		_, _, addr, err := getAllFromMnemonic(key)
+		if err != nil {
+			return AccountInfo{}, err
+		}
+		wallet.NextIndex++
+		// skip the key already held by another account, which must not be replaced
+		if _, ok := kb.index[addr]; ok {
+			continue
+		}
+		child := AccountInfo{Memo: memo, Address: addr, HDPath: &path, Wallet: id}
+		kb.markChanged(addr)
+		kb.index[addr] = len(kb.Accounts)
+		kb.Accounts = append(kb.Accounts, child)
+		return child, nil
+	}
+}
+
+// ChangeWalletPassphrase changes the passphrase shared by the wallet's children, and saves the keybase
+func (kb *MyKeyBase) ChangeWalletPassphrase(id, oldPassphrase, newPassphrase string) error {
+	if kb.IsReadOnly() {
+		return ErrReadOnly
+	}
+	w, ok := kb.GetWallet(id)
+	if !ok {
+		return accountError(id, ErrNoSuchWallet)
+	}
+	key, err := w.decryptMnemonicKey(oldPassphrase)
+	if err != nil {
+		return err
+	}
+	err = w.encryptMnemonicKey(key, newPassphrase, kb.Header().Kdf)
+	if err != nil {
+		return err
+	}
+	err = kb.putWallet(w, false)
+	if err != nil {
+		return err
+	}
+	return kb.Save()
+}
+
+// DeleteWallet removes a wallet and all its children after checking its passphrase, and saves the keybase
+func (kb *MyKeyBase) DeleteWallet(id, passphrase string) error {
+	w, ok := kb.GetWallet(id)
+	if !ok {
+		return accountError(id, ErrNoSuchWallet)
+	}
+	err := w.CheckPassphrase(passphrase)
+	if err != nil {
+		return err
+	}
+	err = kb.removeWallet(id)
+	if err != nil {
+		return err
+	}
+	return kb.Save()
+}
+
+// removeWallet removes a wallet and its children in one go, so nothing is changed on a read-only keybase.
+// The removed children are marked changed, and the wallets are stored along with the header by every Save.
+func (kb *MyKeyBase) removeWallet(id string) error {
+	kb.mtx.Lock()
+	defer kb.mtx.Unlock()
+	if kb.readOnly {
+		return ErrReadOnly
+	}
+	var children []string
+	for _, acc := range kb.Accounts {
+		if acc.Wallet == id {
+			children = append(children, acc.Address)
+		}
+	}
+	for _, addr := range children {
+		kb.removeAccount(addr)
+	}
+	for i := range kb.wallets {
+		if kb.wallets[i].ID == id {
+			kb.wallets = append(kb.wallets[:i], kb.wallets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// walletOf returns the wallet of a child account
+func (kb *MyKeyBase) walletOf(acc AccountInfo) (WalletInfo, error) {
+	w, ok := kb.GetWallet(acc.Wallet)
+	if !ok {
+		return w, accountError(acc.Address, ErrNoSuchWallet)
+	}
+	return w, nil
+}
+
+// checkPassphrase checks the passphrase of an account, which is its wallet's for a child
+func (kb *MyKeyBase) checkPassphrase(acc AccountInfo, passphrase string) error {
+	if acc.Wallet == "" {
+		return acc.CheckPassphrase(passphrase)
+	}
+	w, err := kb.walletOf(acc)
+	if err != nil {
+		return err
+	}
+	return w.CheckPassphrase(passphrase)
+}
+
+// mnemonicKeyOf decrypts the mnemonic of an account, which is its wallet's for a child
+func (kb *MyKeyBase) mnemonicKeyOf(acc AccountInfo, passphrase string) (MnemonicKey, error) {
+	if acc.GetKeyType() != KeyTypeMnemonic {
+		return MnemonicKey{}, accountError(acc.Address, ErrNoMnemonic)
+	}
+	if acc.Wallet == "" {
+		return acc.decryptMnemonicKey(passphrase)
+	}
+	w, err := kb.walletOf(acc)
+	if err != nil {
+		return MnemonicKey{}, err
+	}
+	key, err := w.decryptMnemonicKey(passphrase)
+	key.Path = acc.Path()
+	return key, err
+}
+
+func CreateWallet(memo string, key MnemonicKey, passphrase string) (WalletInfo, error) {
+	return KB.CreateWallet(memo, key, passphrase)
+}
+
+func GetWallet(id string) (WalletInfo, bool) {
+	return KB.GetWallet(id)
+}
+
+func ListWallets() []WalletInfo {
+	return KB.ListWallets()
+}
+
+func ListWalletChildren(id string) []AccountInfo {
+	return KB.ListWalletChildren(id)
+}
+
+func AddWalletChild(id, passphrase, memo string) (AccountInfo, error) {
+	return KB.AddWalletChild(id, passphrase, memo)
+}
+
+func ChangeWalletPassphrase(id, oldPassphrase, newPassphrase string) error {
+	return KB.ChangeWalletPassphrase(id, oldPassphrase, newPassphrase)
+}
+
+func DeleteWallet(id, passphrase string) error {
+	return KB.DeleteWallet(id, passphrase)
+}
@@ -0,0 +1,82 @@
+package keykeeper
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWallet(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "wallet")
+	defer os.RemoveAll(dir)
+	for _, location := range []string{filepath.Join(dir, "kb.json"), filepath.Join(dir, "kb") + "/"} {
+		kb := openTestKeybase(t, location)
+		// the standalone account at index 0 of the same mnemonic is skipped by the children
+		if _, err := kb.CreateAccount("solo", testMnemonic, "p1"); err != nil {
+			t.Fatal(err)
+		}
+		w, err := kb.CreateWallet("w", MnemonicKey{Mnemonic: testMnemonic, Path: DefaultHDPath}, "wp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := kb.CreateWallet("w2", MnemonicKey{Mnemonic: testMnemonic, Path: DefaultHDPath}, "wp"); !errors.Is(err, ErrWalletExists) {
+			t.Fatal(err)
+		}
+		c1, err := kb.AddWalletChild(w.ID, "wp", "c1")
+		if err != nil || c1.Path().Index != 1 {
+			t.Fatal(err, c1.Path())
+		}
+		c2, _ := kb.AddWalletChild(w.ID, "wp", "c2")
+		if _, err := kb.AddWalletChild(w.ID, "bad", "c3"); !errors.Is(err, ErrBadPassphrase) {
+			t.Fatal(err)
+		}
+		if err := kb.ChangePassphrase(c1.Address, "wp", "wp2"); err != nil {
+			t.Fatal(err)
+		}
+		empty, err := kb.CreateWallet("empty", MnemonicKey{Mnemonic: testMnemonic, Path: HDPath{CoinType: CosmosCoinType}}, "ep")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kb.Close()
+
+		kb = openTestKeybase(t, location)
+		if len(kb.ListWalletChildren(w.ID)) != 2 || len(kb.ListWallets()) != 2 {
+			t.Fatal("the wallets are not saved")
+		}
+		if _, err := kb.Sign(c2.Address, "wp2", []byte("x")); err != nil {
+			t.Fatal(err)
+		}
+		kb.Close()
+
+		kb, err = Open(location, OpenOptions{Mode: OpenReadOnly})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kb.DeleteWallet(w.ID, "wp2"); !errors.Is(err, ErrReadOnly) {
+			t.Fatal(err)
+		}
+		if err := kb.DeleteWallet(empty.ID, "ep"); !errors.Is(err, ErrReadOnly) {
+			t.Fatal(err)
+		}
+		if len(kb.ListWalletChildren(w.ID)) != 2 || len(kb.ListWallets()) != 2 {
+			t.Fatal("the read-only keybase is changed")
+		}
+		kb.Close()
+
+		kb = openTestKeybase(t, location)
+		if err := kb.DeleteWallet(w.ID, "wp2"); err != nil {
+			t.Fatal(err)
+		}
+		if err := kb.DeleteWallet(empty.ID, "ep"); err != nil {
+			t.Fatal(err)
+		}
+		kb.Close()
+		kb = openTestKeybase(t, location)
+		if len(kb.GetStringItems()) != 1 || len(kb.ListWallets()) != 0 {
+			t.Fatal("the wallet is not deleted")
+		}
+		kb.Close()
+	}
+}
@@ -0,0 +1,262 @@
+package keykeeper
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	bip39 "github.com/cosmos/go-bip39"
+	"golang.org/x/text/unicode/norm"
+)
+
+// The languages of the BIP39 wordlists, which are also the names of their files in the BIP39 repository
+const (
+	LanguageEnglish            = "english"
+	LanguageChineseSimplified  = "chinese_simplified"
+	LanguageChineseTraditional = "chinese_traditional"
+	LanguageJapanese           = "japanese"
+	LanguageKorean             = "korean"
+	LanguageSpanish            = "spanish"
+	LanguageFrench             = "french"
+	LanguageItalian            = "italian"
+	LanguageCzech              = "czech"
+)
+
+// Languages lists all the supported languages. Only the English wordlist is built in, and the others are
+// loaded by LoadWordlists from the files published in the BIP39 repository, whose digests are pinned.
+var Languages = []string{
+	LanguageEnglish,
+	LanguageChineseSimplified,
+	LanguageChineseTraditional,
+	LanguageJapanese,
+	LanguageKorean,
+	LanguageSpanish,
+	LanguageFrench,
+	LanguageItalian,
+	LanguageCzech,
+}
+
+// WordlistSize is the number of words in every BIP39 wordlist, each word encodes 11 bits
+const WordlistSize = 2048
+
+// wordlistDigests pins the SHA-256 digests of the official wordlist files in the BIP39 repository, which have
+// one word per line and end with a newline. A swapped or reordered list would silently change the words users
+// write down, so a list of another content is refused, and so is a language without a pinned digest.
+var wordlistDigests = map[string]string{
+	LanguageEnglish:            "2f5eed53a4727b4bf8880d8f3f199efc90e58503646d9ff8eff3a2ed3b24dbda",
+	LanguageChineseSimplified:  "5c5942792bd8340cb8b27cd592f1015edf56a8c5b26276ee18a482428e7c5726",
+	LanguageChineseTraditional: "417b26b3d8500a4ae3d59717d7011952db6fc2fb84b807f3f94ac734e89c1b5f",
+	LanguageJapanese:           "2eed0aef492291e061633d7ad8117f1a2b03eb80a29d0e4e3117ac2528d05ffd",
+	LanguageKorean:             "9e95f86c167de88f450f0aaf89e87f6624a57f973c67b516e338e8e8b8897f60",
+	LanguageSpanish:            "46846a5a0139d1e3cb77293e521c2865f7bcdb82c44e8d0a06a2cd0ecba48c0b",
+	LanguageFrench:             "ebc3959ab7801a1df6bac4fa7d970652f1df76b683cd2f4003c941c63d517e59",
+	LanguageItalian:            "d392c49fdb700a24cd1fceb237c1f65dcc128f6b34a8aacb58b59384b5c648c2",
+	LanguageCzech:              "7e80e161c3e93d9554c2efb78d4e3cebf8fc727e9c52e03b83b94406bdcc95fc",
+}
+
+// Wordlist encodes entropy into mnemonics of a language and decodes them back
+type Wordlist struct {
+	Language string
+	Words    []string
+	// Separator joins the words of a mnemonic, which is the ideographic space for Japanese
+	Separator string
+	// index maps the NFKD forms of the words to their positions
+	index map[string]int
+}
+
+var (
+	wordlistsMtx sync.RWMutex
+	wordlists    = map[string]*Wordlist{LanguageEnglish: mustNewWordlist(LanguageEnglish, bip39.EnglishWordList)}
+)
+
+func newWordlist(language string, words []string) (*Wordlist, error) {
+	if len(words) != WordlistSize {
+		return nil, fmt.Errorf("%w: %s has %d words", ErrInvalidWordlist, language, len(words))
+	}
+	digest, ok := wordlistDigests[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: no digest is pinned for the %s wordlist", ErrUnsupportedLanguage, language)
+	}
+	if sum := sha256.Sum256([]byte(strings.Join(words, "\n") + "\n")); hex.EncodeToString(sum[:]) != digest {
+		return nil, fmt.Errorf("%w: %s is not the official wordlist", ErrInvalidWordlist, language)
+	}
+	wl := &Wordlist{Language: language, Words: words, Separator: " ", index: make(map[string]int, WordlistSize)}
+	if language == LanguageJapanese {
+		wl.Separator = "\u3000"
+	}
+	for i, w := range words {
+		key := normalizeWord(w)
+		if key == "" || strings.ContainsAny(key, " \t\r\n") {
+			return nil, fmt.Errorf("%w: %s has an invalid word at line %d", ErrInvalidWordlist, language, i+1)
+		}
+		if _, ok := wl.index[key]; ok {
+			return nil, fmt.Errorf("%w: %s has a duplicated word at line %d", ErrInvalidWordlist, language, i+1)
+		}
+		wl.index[key] = i
+	}
+	return wl, nil
+}
+
+func mustNewWordlist(language string, words []string) *Wordlist {
+	wl, err := newWordlist(language, words)
+	if err != nil {
+		panic(err)
+	}
+	return wl
+}
+
+// normalizeWord returns the form of a word used for lookups, BIP39 requires NFKD for the seed as well
+func normalizeWord(w string) string {
+	return strings.ToLower(norm.NFKD.String(w))
+}
+
+// LoadWordlist adds the wordlist of a language from the content of its file, one word per line.
+// The words must be exactly those of the official file, but a BOM and CRLF line ends are accepted.
+func LoadWordlist(language string, content []byte) error {
+	known := false
+	for _, lang := range Languages {
+		known = known || lang == language
+	}
+	if !known || language == LanguageEnglish {
+		return fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf"))
+	wl, err := newWordlist(language, strings.Fields(string(content)))
+	if err != nil {
+		return err
+	}
+	wordlistsMtx.Lock()
+	defer wordlistsMtx.Unlock()
+	wordlists[language] = wl
+	return nil
+}
+
+// LoadWordlists loads the files named like "japanese.txt" in dir, and returns the loaded languages.
+// The missing files are skipped, so a language is only available when its file is present.
+// A file which can not be loaded does not stop the others, and the first error is returned.
+func LoadWordlists(dir string) (loaded []string, firstErr error) {
+	for _, lang := range Languages[1:] {
+		content, err := ioutil.ReadFile(filepath.Join(dir, lang+".txt"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err == nil {
+			err = LoadWordlist(lang, content)
+		}
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		loaded = append(loaded, lang)
+	}
+	return
+}
+
+// AvailableLanguages returns the languages whose wordlists are loaded, in the order of Languages
+func AvailableLanguages() []string {
+	wordlistsMtx.RLock()
+	defer wordlistsMtx.RUnlock()
+	var res []string
+	for _, lang := range Languages {
+		if _, ok := wordlists[lang]; ok {
+			res = append(res, lang)
+		}
+	}
+	return res
+}
+
+// GetWordlist returns the wordlist of a language, and the empty language stands for English
+func GetWordlist(language string) (*Wordlist, error) {
+	if language == "" {
+		language = LanguageEnglish
+	}
+	wordlistsMtx.RLock()
+	defer wordlistsMtx.RUnlock()
+	wl, ok := wordlists[language]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedLanguage, language)
+	}
+	return wl, nil
+}
+
+// NewMnemonic encodes the entropy, whose size must be one of a mnemonic of MnemonicWordCounts
+func (wl *Wordlist) NewMnemonic(entropy []byte) (string, error) {
+	words := len(entropy) * 8 * 3 / 32
+	if bits, err := MnemonicEntropyBits(words); err != nil || bits != len(entropy)*8 {
+		return "", fmt.Errorf("%w: %d bytes of entropy", ErrInvalidWordCount, len(entropy))
+	}
+	checksumBits := uint(len(entropy) / 4)
+	sum := sha256.Sum256(entropy)
+	n := new(big.Int).SetBytes(entropy)
+	n.Lsh(n, checksumBits)
+	n.Or(n, big.NewInt(int64(sum[0]>>(8-checksumBits))))
+	res := make([]string, words)
+	mask := big.NewInt(WordlistSize - 1)
+	for i := words - 1; i >= 0; i-- {
+		res[i] = wl.Words[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(res, wl.Separator), nil
+}
+
+// Normalize replaces the known words of a mnemonic with their forms in the wordlist, and joins the words
+// with the separator. So a mnemonic typed or pasted in other forms gets the same key.
+func (wl *Wordlist) Normalize(mnemonic string) string {
+	words := strings.Fields(normalizeWord(mnemonic))
+	for i, w := range words {
+		if j, ok := wl.index[w]; ok {
+			words[i] = wl.Words[j]
+		}
+	}
+	return strings.Join(words, wl.Separator)
+}
+
+// Entropy checks the number of words, the words and the checksum of a mnemonic and returns its entropy.
+// It returns a *MnemonicError for an invalid one.
+func (wl *Wordlist) Entropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(normalizeWord(mnemonic))
+	bits, err := MnemonicEntropyBits(len(words))
+	if err != nil {
+		return nil, &MnemonicError{WordCount: len(words)}
+	}
+	n := new(big.Int)
+	for i, w := range words {
+		j, ok := wl.index[w]
+		if !ok {
+			return nil, &MnemonicError{WordCount: len(words), Position: i + 1, Word: w}
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(j)))
+	}
+	checksumBits := uint(bits / 32)
+	checksum := byte(new(big.Int).And(n, big.NewInt(1<<checksumBits-1)).Int64())
+	n.Rsh(n, checksumBits)
+	entropy := make([]byte, bits/8)
+	nb := n.Bytes()
+	copy(entropy[len(entropy)-len(nb):], nb)
+	if sum := sha256.Sum256(entropy); sum[0]>>(8-checksumBits) != checksum {
+		return nil, &MnemonicError{WordCount: len(words), BadChecksum: true}
+	}
+	return entropy, nil
+}
+
+// Validate is like Entropy, but only returns the error
+func (wl *Wordlist) Validate(mnemonic string) error {
+	_, err := wl.Entropy(mnemonic)
+	return err
+}
+
+// mnemonicSeed derives the BIP39 seed from the NFKD forms of the mnemonic and the BIP39 passphrase,
+// which do not change English mnemonics and ASCII passphrases
+func mnemonicSeed(mnemonic, bip39Passphrase string) []byte {
+	return bip39.NewSeed(norm.NFKD.String(mnemonic), norm.NFKD.String(bip39Passphrase))
+}
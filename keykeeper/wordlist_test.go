@@ -0,0 +1,152 @@
+package keykeeper
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// testWordlistDir holds copies of the official files in the BIP39 repository
+const testWordlistDir = "testdata/wordlists"
+
+func readTestWordlist(t *testing.T, language string) []byte {
+	content, err := ioutil.ReadFile(filepath.Join(testWordlistDir, language+".txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content
+}
+
+// loadTestWordlist loads the official wordlist of a language until the returned function is called
+func loadTestWordlist(t *testing.T, language string) func() {
+	if err := LoadWordlist(language, readTestWordlist(t, language)); err != nil {
+		t.Fatal(err)
+	}
+	return func() { unloadWordlists(language) }
+}
+
+func unloadWordlists(languages ...string) {
+	wordlistsMtx.Lock()
+	defer wordlistsMtx.Unlock()
+	for _, lang := range languages {
+		delete(wordlists, lang)
+	}
+}
+
+func TestLoadWordlistDigest(t *testing.T) {
+	english, _ := GetWordlist(LanguageEnglish)
+	if err := LoadWordlist(LanguageKorean, []byte(strings.Join(english.Words, "\n"))); !errors.Is(err, ErrInvalidWordlist) {
+		t.Fatal(err)
+	}
+	if err := LoadWordlist(LanguageEnglish, []byte(strings.Join(english.Words, "\n"))); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Fatal(err)
+	}
+	if err := LoadWordlist("klingon", []byte(strings.Join(english.Words, "\n"))); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Fatal(err)
+	}
+	content := readTestWordlist(t, LanguageFrench)
+	words := strings.Fields(string(content))
+	words[0], words[1] = words[1], words[0]
+	if err := LoadWordlist(LanguageFrench, []byte(strings.Join(words, "\n"))); !errors.Is(err, ErrInvalidWordlist) {
+		t.Fatal(err)
+	}
+	if err := LoadWordlist(LanguageFrench, []byte("a\nb")); !errors.Is(err, ErrInvalidWordlist) {
+		t.Fatal(err)
+	}
+	// a BOM and CRLF line ends, as saved by some editors
+	defer unloadWordlists(LanguageFrench)
+	if err := LoadWordlist(LanguageFrench, append([]byte("\xef\xbb\xbf"), bytes.Replace(content, []byte("\n"), []byte("\r\n"), -1)...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadWordlists(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "wordlists")
+	defer os.RemoveAll(dir)
+	defer unloadWordlists(Languages[1:]...)
+	japanese := readTestWordlist(t, LanguageJapanese)
+	// a bad file before the good ones does not stop them
+	ioutil.WriteFile(filepath.Join(dir, LanguageChineseSimplified+".txt"), japanese, 0600)
+	ioutil.WriteFile(filepath.Join(dir, LanguageJapanese+".txt"), japanese, 0600)
+	ioutil.WriteFile(filepath.Join(dir, LanguageFrench+".txt"), readTestWordlist(t, LanguageFrench), 0600)
+	loaded, err := LoadWordlists(dir)
+	if !errors.Is(err, ErrInvalidWordlist) {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, []string{LanguageJapanese, LanguageFrench}) {
+		t.Fatal(loaded)
+	}
+	if langs := AvailableLanguages(); !reflect.DeepEqual(langs, []string{LanguageEnglish, LanguageJapanese, LanguageFrench}) {
+		t.Fatal(langs)
+	}
+}
+
+func TestJapaneseMnemonic(t *testing.T) {
+	defer loadTestWordlist(t, LanguageJapanese)()
+	wl, err := GetWordlist(LanguageJapanese)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first vector of the Japanese test vectors of BIP39, joined by ideographic spaces.
+	// The official list is in NFKD, so the voiced marks are combining characters.
+	entropy := make([]byte, 16)
+	expected := strings.Repeat(norm.NFKD.String("あいこくしん")+"\u3000", 11) + norm.NFKD.String("あおぞら")
+	mnemonic, err := wl.NewMnemonic(entropy)
+	if err != nil || mnemonic != expected {
+		t.Fatal(err, mnemonic)
+	}
+	res, err := wl.Entropy(strings.Replace(mnemonic, "\u3000", " ", -1))
+	if err != nil || !bytes.Equal(res, entropy) {
+		t.Fatal(err, res)
+	}
+	// typed with composed voiced marks and ASCII spaces
+	if wl.Normalize(strings.Repeat("あいこくしん ", 11)+"あおぞら") != mnemonic {
+		t.Fatal("not normalized")
+	}
+}
+
+func TestMnemonicLanguage(t *testing.T) {
+	unload := loadTestWordlist(t, LanguageFrench)
+	defer unload()
+	kb := openTestKeybase(t, "mem://")
+	defer kb.Close()
+	addr, mnemonic, _, err := GenerateMnemonic("coinex1", "", MnemonicKey{Path: DefaultHDPath, Language: LanguageFrench}, 12, nil, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// typed in upper case
+	acc, err := kb.ImportMnemonic("fr", MnemonicKey{Mnemonic: strings.ToUpper(mnemonic), Path: DefaultHDPath, Language: LanguageFrench}, "p")
+	if err != nil || acc.Address != addr || acc.Language != LanguageFrench {
+		t.Fatal(err, acc.Address, addr)
+	}
+	if _, err := kb.ImportMnemonic("en", MnemonicKey{Mnemonic: mnemonic, Path: DefaultHDPath}, "p"); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Fatal(err)
+	}
+	en, err := kb.ImportMnemonic("en", MnemonicKey{Mnemonic: testMnemonic, Path: DefaultHDPath, Language: LanguageEnglish}, "p")
+	if err != nil || en.Language != "" || en.Address != testAddress {
+		t.Fatal(err, en)
+	}
+
+	// the account keeps working without its wordlist
+	unload()
+	if _, err := kb.Sign(addr, "p", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := kb.ExportPrivKeyHex(addr, "p"); err != nil {
+		t.Fatal(err)
+	}
+	key, err := kb.GetMnemonicKey(addr, "p")
+	if err != nil || key.Mnemonic != mnemonic || key.Language != LanguageFrench {
+		t.Fatal(err, key)
+	}
+	if _, err := kb.ImportMnemonic("fr2", key, "p"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Fatal(err)
+	}
+}
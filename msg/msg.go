@@ -6,6 +6,27 @@ import (
 	"errors"
 )
 
+var (
+	ErrUnknownTxType     = errors.New("Invalid tx_type")
+	ErrInvalidVoteOption = errors.New("Invalid vote option")
+	ErrInvalidRawTx      = errors.New("Invalid tx for 'raw': it is not a slice of two strings")
+)
+
+// FieldError records which field of a message has an invalid value
+type FieldError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error() + ": " + e.Value
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
 type Msg struct {
 	Tx            interface{} `json:"tx"`
 	TxType        string      `json:"tx_type"`
@@ -82,18 +103,18 @@ func ParseJson(jsonStr string) (msg Msg, err error) {
 		var tx VoteDetail
 		err = json.Unmarshal(bz, &tx)
 		if tx.Option != "Yes" && tx.Option != "No" && tx.Option != "Abstain" && tx.Option != "NoWithVeto" {
-			err = errors.New("Invalid Option: "+tx.Option)
+			err = &FieldError{Field: "option", Value: tx.Option, Err: ErrInvalidVoteOption}
 		}
 		msg.Tx = tx
 	case "raw":
 		var raw []string
 		err = json.Unmarshal(bz, &raw)
-		if err != nil && len(raw) != 2 {
-			err = errors.New("Invalid tx for 'raw': it is not a string slice")
+		if err != nil || len(raw) != 2 {
+			err = ErrInvalidRawTx
 		}
 		msg.Tx = raw
 	default:
-		err = errors.New("Invalid tx_type: "+msg.TxType)
+		err = &FieldError{Field: "tx_type", Value: msg.TxType, Err: ErrUnknownTxType}
 	}
 	return
 }